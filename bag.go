@@ -0,0 +1,426 @@
+package set
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// BagInterface is describing a Bag. Bags, or multisets, are unordered lists
+// of values which, unlike sets, remember how many times each value was added.
+type BagInterface interface {
+	New() BagInterface
+	Add(item interface{}, n int)
+	Remove(item interface{}, n int)
+	Count(item interface{}) int
+	Size() int
+	IsEmpty() bool
+	Clear()
+	Distinct() *Set
+	Each(func(item interface{}, n int) bool)
+	MostCommon(k int) []BagEntry
+	Union(t BagInterface) BagInterface
+	Sum(t BagInterface) BagInterface
+	Intersection(t BagInterface) BagInterface
+	Difference(t BagInterface) BagInterface
+	Copy() BagInterface
+	String() string
+}
+
+// BagEntry is an item of a bag together with its count.
+type BagEntry struct {
+	Item  interface{}
+	Count int
+}
+
+// Provides a common baseline for both threadsafe and non-ts bags. m holds
+// the count of every item, which is always positive; size is their sum.
+type bag struct {
+	m    map[interface{}]int
+	size int
+}
+
+// BagNonTS defines a non-thread safe multiset.
+type BagNonTS struct {
+	bag
+}
+
+// Bag defines a thread safe multiset.
+type Bag struct {
+	bag
+	setLock
+}
+
+// NewBagNonTS creates and initialize a new non-threadsafe BagNonTS. It
+// accepts a variable number of arguments to populate the initial bag, every
+// argument counts once.
+func NewBagNonTS(items ...interface{}) *BagNonTS {
+	b := &BagNonTS{}
+	b.m = make(map[interface{}]int)
+
+	// Ensure interface compliance
+	var _ BagInterface = b
+
+	for _, item := range items {
+		b.Add(item, 1)
+	}
+	return b
+}
+
+// NewBag creates and initialize a new Bag. It accepts a variable number of
+// arguments to populate the initial bag, every argument counts once.
+func NewBag(items ...interface{}) *Bag {
+	b := &Bag{}
+	b.m = make(map[interface{}]int)
+
+	// Ensure interface compliance
+	var _ BagInterface = b
+
+	for _, item := range items {
+		b.bag.Add(item, 1)
+	}
+	return b
+}
+
+// NewBagFrom creates a new Bag with every item of s counted once.
+func NewBagFrom(s Interface) *Bag {
+	b := NewBag()
+
+	unlock := lockSets(nil, s)
+	defer unlock()
+
+	view(s).Each(func(item interface{}) bool {
+		b.bag.Add(item, 1)
+		return true
+	})
+	return b
+}
+
+// viewBag returns a version of t that does not lock. It must only be used
+// while t is locked with lockSets.
+func viewBag(t BagInterface) BagInterface {
+	if conv, ok := t.(interface{ unlocked() BagInterface }); ok {
+		return conv.unlocked()
+	}
+	return t
+}
+
+// New creates and initalizes a new empty BagNonTS.
+func (b *bag) New() BagInterface {
+	return NewBagNonTS()
+}
+
+// Add adds n copies of item to the bag. It panics if n is negative.
+func (b *bag) Add(item interface{}, n int) {
+	if n < 0 {
+		panic(fmt.Errorf("set: cannot add %d copies of %v to a bag", n, item))
+	}
+	if n == 0 {
+		return
+	}
+
+	b.m[item] += n
+	b.size += n
+}
+
+// Remove removes n copies of item from the bag, or all if there are fewer.
+// It panics if n is negative.
+func (b *bag) Remove(item interface{}, n int) {
+	if n < 0 {
+		panic(fmt.Errorf("set: cannot remove %d copies of %v from a bag", n, item))
+	}
+
+	count, ok := b.m[item]
+	if !ok {
+		return
+	}
+
+	if n >= count {
+		delete(b.m, item)
+		b.size -= count
+	} else {
+		b.m[item] = count - n
+		b.size -= n
+	}
+}
+
+// Count returns how many copies of item the bag holds.
+func (b *bag) Count(item interface{}) int {
+	return b.m[item]
+}
+
+// Size returns the number of items in the bag, counting every copy.
+func (b *bag) Size() int {
+	return b.size
+}
+
+// IsEmpty reports whether the bag is empty.
+func (b *bag) IsEmpty() bool {
+	return b.size == 0
+}
+
+// Clear removes all items from the bag.
+func (b *bag) Clear() {
+	b.m = make(map[interface{}]int)
+	b.size = 0
+}
+
+// Distinct returns a new Set with every item of the bag once.
+func (b *bag) Distinct() *Set {
+	s := New()
+	for item := range b.m {
+		s.m[item] = keyExists
+	}
+	return s
+}
+
+// Each traverses the distinct items of the bag, calling the provided
+// function with each item and its count. Traversal will continue until all
+// items have been visited, or if the closure returns false.
+func (b *bag) Each(f func(item interface{}, n int) bool) {
+	for item, n := range b.m {
+		if !f(item, n) {
+			break
+		}
+	}
+}
+
+// MostCommon returns the k items with the highest counts, highest first.
+// Items with the same count are in no particular order. If k is negative or
+// larger than the number of distinct items, all items are returned.
+func (b *bag) MostCommon(k int) []BagEntry {
+	entries := make([]BagEntry, 0, len(b.m))
+	for item, n := range b.m {
+		entries = append(entries, BagEntry{item, n})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Count > entries[j].Count
+	})
+
+	if k >= 0 && k < len(entries) {
+		entries = entries[:k]
+	}
+	return entries
+}
+
+// Union returns a new bag holding every item with the larger of its counts
+// in b and t.
+func (b *bag) Union(t BagInterface) BagInterface {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return combineBags(b.New(), b, viewBag(t), func(x, y int) int { return max(x, y) })
+}
+
+// Sum returns a new bag holding every item with the sum of its counts in b
+// and t.
+func (b *bag) Sum(t BagInterface) BagInterface {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return combineBags(b.New(), b, viewBag(t), func(x, y int) int { return x + y })
+}
+
+// Intersection returns a new bag holding every item with the smaller of its
+// counts in b and t.
+func (b *bag) Intersection(t BagInterface) BagInterface {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return combineBags(b.New(), b, viewBag(t), func(x, y int) int { return min(x, y) })
+}
+
+// Difference returns a new bag holding every item with its count in b less
+// its count in t, if that is positive.
+func (b *bag) Difference(t BagInterface) BagInterface {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return combineBags(b.New(), b, viewBag(t), func(x, y int) int { return x - y })
+}
+
+// Copy returns a new BagNonTS with a copy of b.
+func (b *bag) Copy() BagInterface {
+	c := NewBagNonTS()
+	b.copyTo(&c.bag)
+	return c
+}
+
+func (b *bag) copyTo(c *bag) {
+	for item, n := range b.m {
+		c.m[item] = n
+	}
+	c.size = b.size
+}
+
+// String returns a string representation of b, listing every item with its
+// count.
+func (b *bag) String() string {
+	t := make([]string, 0, len(b.m))
+	for item, n := range b.m {
+		t = append(t, fmt.Sprintf("%v:%d", item, n))
+	}
+
+	return fmt.Sprintf("[%s]", strings.Join(t, ", "))
+}
+
+// New creates and initalizes a new empty Bag.
+func (b *Bag) New() BagInterface {
+	return NewBag()
+}
+
+// Add adds n copies of item to the bag. It panics if n is negative.
+func (b *Bag) Add(item interface{}, n int) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	b.bag.Add(item, n)
+}
+
+// Remove removes n copies of item from the bag, or all if there are fewer.
+// It panics if n is negative.
+func (b *Bag) Remove(item interface{}, n int) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	b.bag.Remove(item, n)
+}
+
+// Count returns how many copies of item the bag holds.
+func (b *Bag) Count(item interface{}) int {
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	return b.m[item]
+}
+
+// Size returns the number of items in the bag, counting every copy.
+func (b *Bag) Size() int {
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	return b.size
+}
+
+// IsEmpty reports whether the bag is empty.
+func (b *Bag) IsEmpty() bool {
+	return b.Size() == 0
+}
+
+// Clear removes all items from the bag.
+func (b *Bag) Clear() {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	b.bag.Clear()
+}
+
+// Distinct returns a new Set with every item of the bag once.
+func (b *Bag) Distinct() *Set {
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	return b.bag.Distinct()
+}
+
+// Each traverses the distinct items of the bag, calling the provided
+// function with each item and its count. Traversal will continue until all
+// items have been visited, or if the closure returns false.
+func (b *Bag) Each(f func(item interface{}, n int) bool) {
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	b.bag.Each(f)
+}
+
+// MostCommon returns the k items with the highest counts, highest first.
+// Items with the same count are in no particular order. If k is negative or
+// larger than the number of distinct items, all items are returned.
+func (b *Bag) MostCommon(k int) []BagEntry {
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	return b.bag.MostCommon(k)
+}
+
+// Union returns a new bag holding every item with the larger of its counts
+// in b and t.
+func (b *Bag) Union(t BagInterface) BagInterface {
+	unlock := lockSets(nil, b, t)
+	defer unlock()
+
+	return combineBags(b.New(), &b.bag, viewBag(t), func(x, y int) int { return max(x, y) })
+}
+
+// Sum returns a new bag holding every item with the sum of its counts in b
+// and t.
+func (b *Bag) Sum(t BagInterface) BagInterface {
+	unlock := lockSets(nil, b, t)
+	defer unlock()
+
+	return combineBags(b.New(), &b.bag, viewBag(t), func(x, y int) int { return x + y })
+}
+
+// Intersection returns a new bag holding every item with the smaller of its
+// counts in b and t.
+func (b *Bag) Intersection(t BagInterface) BagInterface {
+	unlock := lockSets(nil, b, t)
+	defer unlock()
+
+	return combineBags(b.New(), &b.bag, viewBag(t), func(x, y int) int { return min(x, y) })
+}
+
+// Difference returns a new bag holding every item with its count in b less
+// its count in t, if that is positive.
+func (b *Bag) Difference(t BagInterface) BagInterface {
+	unlock := lockSets(nil, b, t)
+	defer unlock()
+
+	return combineBags(b.New(), &b.bag, viewBag(t), func(x, y int) int { return x - y })
+}
+
+// Copy returns a new Bag with a copy of b.
+func (b *Bag) Copy() BagInterface {
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	c := NewBag()
+	b.copyTo(&c.bag)
+	return c
+}
+
+// String returns a string representation of b, listing every item with its
+// count.
+func (b *Bag) String() string {
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	return b.bag.String()
+}
+
+// unlocked returns the underlying bag of b, which does not lock.
+func (b *Bag) unlocked() BagInterface {
+	return &b.bag
+}
+
+// combineBags adds every item of a or t to res, which is not shared yet,
+// with the count op returns for its counts in a and t, if that is positive.
+func combineBags(res BagInterface, a *bag, t BagInterface, op func(x, y int) int) BagInterface {
+	r := viewBag(res)
+
+	for item, n := range a.m {
+		if c := op(n, t.Count(item)); c > 0 {
+			r.Add(item, c)
+		}
+	}
+	t.Each(func(item interface{}, n int) bool {
+		if _, ok := a.m[item]; !ok {
+			if c := op(0, n); c > 0 {
+				r.Add(item, c)
+			}
+		}
+		return true
+	})
+	return res
+}
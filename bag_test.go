@@ -0,0 +1,186 @@
+package set
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestBag_Add(t *testing.T) {
+	for _, b := range []BagInterface{NewBag(), NewBagNonTS()} {
+		b.Add("a", 3)
+		b.Add("b", 1)
+		b.Add("a", 2)
+		b.Add("c", 0)
+
+		if b.Count("a") != 5 || b.Count("b") != 1 || b.Count("c") != 0 {
+			t.Error("Add: should add n copies of item, got", b)
+		}
+		if b.Size() != 6 {
+			t.Error("Size: should count every copy, got", b.Size())
+		}
+	}
+}
+
+func TestBag_Add_Negative(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Add: should panic for a negative count")
+		}
+	}()
+
+	NewBag().Add("a", -1)
+}
+
+func TestBag_Remove(t *testing.T) {
+	for _, b := range []BagInterface{NewBag("a", "a", "a", "b"), NewBagNonTS("a", "a", "a", "b")} {
+		b.Remove("a", 2)
+		if b.Count("a") != 1 || b.Size() != 2 {
+			t.Error("Remove: should remove n copies of item, got", b)
+		}
+
+		b.Remove("a", 5)
+		b.Remove("z", 1)
+		if b.Count("a") != 0 || b.Size() != 1 || b.Distinct().Has("a") {
+			t.Error("Remove: should remove all copies if there are fewer than n, got", b)
+		}
+
+		b.Remove("b", 1)
+		if !b.IsEmpty() {
+			t.Error("IsEmpty: should be empty after removing all items")
+		}
+	}
+}
+
+func TestBag_Distinct(t *testing.T) {
+	b := NewBag("a", "a", "b", 1, 1, 1)
+
+	s := b.Distinct()
+	if !s.IsEqual(New("a", "b", 1)) {
+		t.Error("Distinct: should return every item once, got", s)
+	}
+
+	f := NewBagFrom(New("a", "b"))
+	if f.Count("a") != 1 || f.Count("b") != 1 || f.Size() != 2 {
+		t.Error("NewBagFrom: should count every item of the set once, got", f)
+	}
+}
+
+func TestBag_MostCommon(t *testing.T) {
+	b := NewBagNonTS()
+	b.Add("the", 10)
+	b.Add("of", 7)
+	b.Add("set", 3)
+	b.Add("bag", 1)
+
+	got := b.MostCommon(2)
+	if len(got) != 2 || got[0] != (BagEntry{"the", 10}) || got[1] != (BagEntry{"of", 7}) {
+		t.Error("MostCommon: should return the items with the highest counts, got", got)
+	}
+
+	if got := b.MostCommon(-1); len(got) != 4 || got[3] != (BagEntry{"bag", 1}) {
+		t.Error("MostCommon: should return all items for a negative k, got", got)
+	}
+	if got := b.MostCommon(10); len(got) != 4 {
+		t.Error("MostCommon: should return all items if k is larger than the bag, got", got)
+	}
+}
+
+func TestBag_Operations(t *testing.T) {
+	counts := func(b BagInterface) map[interface{}]int {
+		m := make(map[interface{}]int)
+		b.Each(func(item interface{}, n int) bool {
+			m[item] = n
+			return true
+		})
+		return m
+	}
+	equal := func(b BagInterface, want map[interface{}]int) bool {
+		got := counts(b)
+		if len(got) != len(want) {
+			return false
+		}
+		size := 0
+		for item, n := range want {
+			if got[item] != n {
+				return false
+			}
+			size += n
+		}
+		return b.Size() == size
+	}
+
+	for _, pair := range [][2]BagInterface{
+		{NewBag(), NewBag()},
+		{NewBagNonTS(), NewBag()},
+		{NewBag(), NewBagNonTS()},
+	} {
+		a, b := pair[0], pair[1]
+		a.Add("x", 3)
+		a.Add("y", 1)
+		b.Add("x", 1)
+		b.Add("y", 2)
+		b.Add("z", 4)
+
+		if u := a.Union(b); !equal(u, map[interface{}]int{"x": 3, "y": 2, "z": 4}) {
+			t.Error("Union: should take the larger count, got", u)
+		}
+		if s := a.Sum(b); !equal(s, map[interface{}]int{"x": 4, "y": 3, "z": 4}) {
+			t.Error("Sum: should add the counts, got", s)
+		}
+		if i := a.Intersection(b); !equal(i, map[interface{}]int{"x": 1, "y": 1}) {
+			t.Error("Intersection: should take the smaller count, got", i)
+		}
+		if d := a.Difference(b); !equal(d, map[interface{}]int{"x": 2}) {
+			t.Error("Difference: should subtract the counts, got", d)
+		}
+		if d := b.Difference(a); !equal(d, map[interface{}]int{"y": 1, "z": 4}) {
+			t.Error("Difference: should subtract the counts, got", d)
+		}
+		if s := a.Sum(a); !equal(s, map[interface{}]int{"x": 6, "y": 2}) {
+			t.Error("Sum: should work with itself, got", s)
+		}
+
+		if !equal(a, map[interface{}]int{"x": 3, "y": 1}) {
+			t.Error("Union: should not change the bags, got", a)
+		}
+	}
+}
+
+func TestBag_Copy(t *testing.T) {
+	b := NewBag("a", "a", "b")
+	c := b.Copy()
+	c.Add("a", 1)
+
+	if b.Count("a") != 2 || c.Count("a") != 3 || c.Size() != 4 {
+		t.Error("Copy: should return an independent copy, got", c)
+	}
+	if _, ok := c.(*Bag); !ok {
+		t.Error("Copy: should return a Bag")
+	}
+}
+
+func TestBag_Race(t *testing.T) {
+	b := NewBag()
+	other := NewBag("a", "b")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				b.Add(j%10, 2)
+				b.Remove(j%10, 1)
+				b.Count(j % 10)
+				b.MostCommon(3)
+				b.Union(other)
+				other.Sum(b)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if b.Size() != 4000 {
+		t.Error("Add: should not lose any copies, got", b.Size())
+	}
+}
@@ -0,0 +1,360 @@
+package set
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// binaryVersion is the version of the binary set encoding written by
+// MarshalBinary.
+//
+// The encoding is the version byte, the number of items as uvarint and then
+// every item as a one byte type tag followed by its value. Items of other
+// types than the tagged scalar kinds are written as tagGob; their values
+// follow all items as one length-prefixed gob stream, so gob type information
+// is sent only once per type.
+const binaryVersion = 1
+
+// type tags of the binary encoding, never reorder.
+const (
+	tagNil byte = iota
+	tagFalse
+	tagTrue
+	tagInt
+	tagInt8
+	tagInt16
+	tagInt32
+	tagInt64
+	tagUint
+	tagUint8
+	tagUint16
+	tagUint32
+	tagUint64
+	tagUintptr
+	tagFloat32
+	tagFloat64
+	tagComplex64
+	tagComplex128
+	tagString
+	tagGob
+)
+
+// errBinaryTruncated is returned for input that ends in the middle of an item.
+var errBinaryTruncated = errors.New("set: binary data is truncated")
+
+// MarshalBinary implements encoding.BinaryMarshaler. Items of the scalar
+// built-in kinds are encoded directly; other types are encoded with gob and
+// must be registered with gob.Register.
+func (s *set) MarshalBinary() ([]byte, error) {
+	return marshalBinaryItems(s.List())
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It replaces the
+// items of s with the decoded ones. On error s is unchanged.
+func (s *set) UnmarshalBinary(data []byte) error {
+	m, err := unmarshalBinaryItems(data)
+	if err != nil {
+		return err
+	}
+
+	s.m = m
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder using the binary encoding.
+func (s *set) GobEncode() ([]byte, error) {
+	return s.MarshalBinary()
+}
+
+// GobDecode implements gob.GobDecoder using the binary encoding.
+func (s *set) GobDecode(data []byte) error {
+	return s.UnmarshalBinary(data)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. Items of the scalar
+// built-in kinds are encoded directly; other types are encoded with gob and
+// must be registered with gob.Register.
+func (s *Set) MarshalBinary() ([]byte, error) {
+	return marshalBinaryItems(s.List())
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It replaces the
+// items of s with the decoded ones. On error s is unchanged.
+func (s *Set) UnmarshalBinary(data []byte) error {
+	m, err := unmarshalBinaryItems(data)
+	if err != nil {
+		return err
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.m = m
+	s.shared.Store(false)
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder using the binary encoding.
+func (s *Set) GobEncode() ([]byte, error) {
+	return s.MarshalBinary()
+}
+
+// GobDecode implements gob.GobDecoder using the binary encoding.
+func (s *Set) GobDecode(data []byte) error {
+	return s.UnmarshalBinary(data)
+}
+
+func marshalBinaryItems(items []interface{}) ([]byte, error) {
+	buf := make([]byte, 0, 1+binary.MaxVarintLen64+len(items)*9)
+	buf = append(buf, binaryVersion)
+	buf = binary.AppendUvarint(buf, uint64(len(items)))
+
+	var gobBuf bytes.Buffer
+	var enc *gob.Encoder
+
+	for _, item := range items {
+		var ok bool
+		if buf, ok = appendScalar(buf, item); ok {
+			continue
+		}
+
+		if enc == nil {
+			enc = gob.NewEncoder(&gobBuf)
+		}
+		if err := enc.Encode(&item); err != nil {
+			return nil, fmt.Errorf("set: cannot encode item of type %T: %w", item, err)
+		}
+		buf = append(buf, tagGob)
+	}
+
+	buf = binary.AppendUvarint(buf, uint64(gobBuf.Len()))
+	return append(buf, gobBuf.Bytes()...), nil
+}
+
+// appendScalar appends item as its type tag and value if it is of one of the
+// scalar built-in kinds, ok is false otherwise.
+func appendScalar(buf []byte, item interface{}) (_ []byte, ok bool) {
+	switch v := item.(type) {
+	case nil:
+		buf = append(buf, tagNil)
+	case bool:
+		if v {
+			buf = append(buf, tagTrue)
+		} else {
+			buf = append(buf, tagFalse)
+		}
+	case int:
+		buf = binary.AppendVarint(append(buf, tagInt), int64(v))
+	case int8:
+		buf = binary.AppendVarint(append(buf, tagInt8), int64(v))
+	case int16:
+		buf = binary.AppendVarint(append(buf, tagInt16), int64(v))
+	case int32:
+		buf = binary.AppendVarint(append(buf, tagInt32), int64(v))
+	case int64:
+		buf = binary.AppendVarint(append(buf, tagInt64), v)
+	case uint:
+		buf = binary.AppendUvarint(append(buf, tagUint), uint64(v))
+	case uint8:
+		buf = binary.AppendUvarint(append(buf, tagUint8), uint64(v))
+	case uint16:
+		buf = binary.AppendUvarint(append(buf, tagUint16), uint64(v))
+	case uint32:
+		buf = binary.AppendUvarint(append(buf, tagUint32), uint64(v))
+	case uint64:
+		buf = binary.AppendUvarint(append(buf, tagUint64), v)
+	case uintptr:
+		buf = binary.AppendUvarint(append(buf, tagUintptr), uint64(v))
+	case float32:
+		buf = binary.LittleEndian.AppendUint32(append(buf, tagFloat32), math.Float32bits(v))
+	case float64:
+		buf = binary.LittleEndian.AppendUint64(append(buf, tagFloat64), math.Float64bits(v))
+	case complex64:
+		buf = binary.LittleEndian.AppendUint32(append(buf, tagComplex64), math.Float32bits(real(v)))
+		buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(imag(v)))
+	case complex128:
+		buf = binary.LittleEndian.AppendUint64(append(buf, tagComplex128), math.Float64bits(real(v)))
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(imag(v)))
+	case string:
+		buf = binary.AppendUvarint(append(buf, tagString), uint64(len(v)))
+		buf = append(buf, v...)
+	default:
+		return buf, false
+	}
+	return buf, true
+}
+
+// binaryReader reads the values of the binary encoding.
+type binaryReader struct {
+	data []byte
+	err  error
+}
+
+func (r *binaryReader) next(n int) []byte {
+	if r.err != nil || n > len(r.data) {
+		r.err = errBinaryTruncated
+		return nil
+	}
+
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *binaryReader) byte() byte {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *binaryReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.err = errBinaryTruncated
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *binaryReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.err = errBinaryTruncated
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *binaryReader) uint16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *binaryReader) uint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *binaryReader) uint64() uint64 {
+	if b := r.next(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+func unmarshalBinaryItems(data []byte) (map[interface{}]struct{}, error) {
+	r := &binaryReader{data: data}
+
+	if len(data) == 0 {
+		return nil, errBinaryTruncated
+	}
+	if v := r.byte(); v != binaryVersion {
+		return nil, fmt.Errorf("set: unsupported binary encoding version %d", v)
+	}
+
+	count := r.uvarint()
+	// every item takes at least one byte, don't trust count any further
+	m := make(map[interface{}]struct{}, min(count, uint64(len(r.data))))
+	gobItems := 0
+
+	for i := uint64(0); i < count && r.err == nil; i++ {
+		var item interface{}
+
+		switch tag := r.byte(); tag {
+		case tagNil:
+			item = nil
+		case tagFalse:
+			item = false
+		case tagTrue:
+			item = true
+		case tagInt:
+			item = int(r.varint())
+		case tagInt8:
+			item = int8(r.varint())
+		case tagInt16:
+			item = int16(r.varint())
+		case tagInt32:
+			item = int32(r.varint())
+		case tagInt64:
+			item = r.varint()
+		case tagUint:
+			item = uint(r.uvarint())
+		case tagUint8:
+			item = uint8(r.uvarint())
+		case tagUint16:
+			item = uint16(r.uvarint())
+		case tagUint32:
+			item = uint32(r.uvarint())
+		case tagUint64:
+			item = r.uvarint()
+		case tagUintptr:
+			item = uintptr(r.uvarint())
+		case tagFloat32:
+			item = math.Float32frombits(r.uint32())
+		case tagFloat64:
+			item = math.Float64frombits(r.uint64())
+		case tagComplex64:
+			re := math.Float32frombits(r.uint32())
+			item = complex(re, math.Float32frombits(r.uint32()))
+		case tagComplex128:
+			re := math.Float64frombits(r.uint64())
+			item = complex(re, math.Float64frombits(r.uint64()))
+		case tagString:
+			n := r.uvarint()
+			if n > uint64(len(r.data)) {
+				return nil, errBinaryTruncated
+			}
+			item = string(r.next(int(n)))
+		case tagGob:
+			gobItems++
+			continue
+		default:
+			if r.err == nil {
+				return nil, fmt.Errorf("set: unknown binary type tag %d", tag)
+			}
+		}
+
+		m[item] = keyExists
+	}
+
+	n := r.uvarint()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if n != uint64(len(r.data)) {
+		return nil, fmt.Errorf("set: binary gob section has %d bytes, want %d", len(r.data), n)
+	}
+
+	dec := gob.NewDecoder(bytes.NewReader(r.data))
+	for i := 0; i < gobItems; i++ {
+		var item interface{}
+		if err := dec.Decode(&item); err != nil {
+			return nil, fmt.Errorf("set: cannot decode gob item: %w", err)
+		}
+		if err := checkHashable([]interface{}{item}); err != nil {
+			return nil, fmt.Errorf("set: cannot decode gob item: %w", err)
+		}
+		m[item] = keyExists
+	}
+
+	return m, nil
+}
@@ -0,0 +1,152 @@
+package set
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math"
+	"testing"
+)
+
+type binaryTestID struct {
+	Zone string
+	N    int
+}
+
+func init() {
+	gob.Register(binaryTestID{})
+}
+
+func TestSet_Binary(t *testing.T) {
+	s := New(nil, true, false, -1, int8(-2), int16(3), int32(-4), int64(5),
+		uint(6), uint8(7), uint16(8), uint32(9), uint64(math.MaxUint64), uintptr(10),
+		float32(1.5), 2.5, complex64(1+2i), 3+4i, "", "istanbul",
+		binaryTestID{"eu", 1}, binaryTestID{"us", 2})
+
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatal("MarshalBinary:", err)
+	}
+
+	u := NewNonTS("old")
+	if err := u.UnmarshalBinary(data); err != nil {
+		t.Fatal("UnmarshalBinary:", err)
+	}
+
+	if !u.IsEqual(s) {
+		t.Error("UnmarshalBinary: round trip lost items, got", u)
+	}
+
+	if data[0] != binaryVersion {
+		t.Error("MarshalBinary: data should start with the version")
+	}
+}
+
+func TestSet_BinaryCompact(t *testing.T) {
+	s := NewNonTS()
+	for i := 0; i < 1000; i++ {
+		s.Add(i)
+	}
+
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatal("MarshalBinary:", err)
+	}
+
+	// a tag byte and at most two varint bytes per item
+	if len(data) > 3*1000+8 {
+		t.Error("MarshalBinary: encoding of small ints should be compact, got", len(data), "bytes")
+	}
+}
+
+func TestSet_BinaryErrors(t *testing.T) {
+	type unregistered struct{ A int }
+	if _, err := New(unregistered{1}).MarshalBinary(); err == nil {
+		t.Error("MarshalBinary: unregistered types should produce an error")
+	}
+
+	data, _ := New("a", 1).MarshalBinary()
+	for i := range data {
+		if err := New().UnmarshalBinary(data[:i]); err == nil {
+			t.Error("UnmarshalBinary: truncated data should produce an error, length", i)
+		}
+	}
+
+	bad := append([]byte{}, data...)
+	bad[0] = 99
+	s := New("keep")
+	if err := s.UnmarshalBinary(bad); err == nil || !s.Has("keep") {
+		t.Error("UnmarshalBinary: unknown version should produce an error and keep the set")
+	}
+}
+
+func TestSet_Gob(t *testing.T) {
+	type snapshot struct {
+		Name  string
+		Items *Set
+		Other *SetNonTS
+	}
+
+	in := snapshot{"ids", New("a", 1, binaryTestID{"eu", 3}), NewNonTS(2.5)}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatal("GobEncode:", err)
+	}
+
+	var out snapshot
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatal("GobDecode:", err)
+	}
+
+	if out.Name != "ids" || !out.Items.IsEqual(in.Items) || !out.Other.IsEqual(in.Other) {
+		t.Error("GobDecode: round trip lost items, got", out.Items, out.Other)
+	}
+}
+
+func hasNaN(s Interface) bool {
+	nan := false
+	s.Each(func(item interface{}) bool {
+		nan = item != item
+		return !nan
+	})
+	return nan
+}
+
+func FuzzSet_Binary(f *testing.F) {
+	data, _ := New("a", 1, 2.5, binaryTestID{"eu", 1}).MarshalBinary()
+	f.Add(data, "istanbul", int64(-3), 1.5, true)
+	f.Add([]byte{binaryVersion, 2, tagString, 200}, "", int64(0), 0.0, false)
+
+	f.Fuzz(func(t *testing.T, data []byte, str string, i int64, fl float64, b bool) {
+		// arbitrary input must never panic, and what decodes must encode
+		// back to the same set
+		s := NewNonTS()
+		if err := s.UnmarshalBinary(data); err == nil && !hasNaN(s) {
+			again, err := s.MarshalBinary()
+			if err != nil {
+				t.Fatal("MarshalBinary:", err)
+			}
+
+			u := NewNonTS()
+			if err := u.UnmarshalBinary(again); err != nil || !u.IsEqual(s) {
+				t.Fatal("UnmarshalBinary: round trip of decoded data failed", err)
+			}
+		}
+
+		items := New(str, i, int(i), int32(i), uint64(i), uint8(i), fl, float32(fl), b,
+			complex(fl, 1), binaryTestID{str, int(i)})
+		if hasNaN(items) {
+			return
+		}
+
+		data, err := items.MarshalBinary()
+		if err != nil {
+			t.Fatal("MarshalBinary:", err)
+		}
+
+		u := New()
+		if err := u.UnmarshalBinary(data); err != nil || !u.IsEqual(items) {
+			t.Fatal("UnmarshalBinary: round trip failed", err)
+		}
+	})
+}
@@ -0,0 +1,663 @@
+package set
+
+import (
+	"fmt"
+	"iter"
+	"math"
+	"math/bits"
+)
+
+// MaxBitItem is the largest item of a bit set. It bounds the memory of a bit
+// set to 256 MiB, so a stray large int panics instead of allocating its
+// words.
+const MaxBitItem = math.MaxInt32
+
+// Provides a common baseline for both threadsafe and non-ts bit sets. Bit i
+// of words[i/64] is set if i is in the set, n caches the number of set bits.
+type bitSet struct {
+	words []uint64
+	n     int
+}
+
+// BitSetNonTS defines a non-thread safe set data structure for dense
+// non-negative ints, such as row ids. Every possible item takes one bit, so
+// it is far smaller than a map based set as long as the items are not too
+// sparse. The set grows automatically to hold the largest item.
+//
+// Only ints from 0 to MaxBitItem can be members. Add panics for other items,
+// Has reports false for them. Each and List visit the items in ascending order.
+// Union, Intersection, Difference, Merge and Separate work a word of 64 items
+// at a time if all sets involved are bit sets.
+type BitSetNonTS struct {
+	bitSet
+}
+
+// BitSet defines a thread safe set data structure for dense non-negative
+// ints, such as row ids. Every possible item takes one bit, so it is far
+// smaller than a map based set as long as the items are not too sparse. The
+// set grows automatically to hold the largest item.
+//
+// Only ints from 0 to MaxBitItem can be members. Add panics for other items,
+// Has reports false for them. Each and List visit the items in ascending order.
+// Union, Intersection, Difference, Merge and Separate work a word of 64 items
+// at a time if all sets involved are bit sets.
+type BitSet struct {
+	bitSet
+	setLock
+}
+
+// NewBitSetNonTS creates and initialize a new non-threadsafe BitSetNonTS. It
+// accepts a variable number of arguments to populate the initial set.
+func NewBitSetNonTS(items ...interface{}) *BitSetNonTS {
+	s := &BitSetNonTS{}
+
+	// Ensure interface compliance
+	var _ Interface = s
+
+	s.Add(items...)
+	return s
+}
+
+// NewBitSet creates and initialize a new BitSet. It accepts a variable number
+// of arguments to populate the initial set.
+func NewBitSet(items ...interface{}) *BitSet {
+	s := &BitSet{}
+
+	// Ensure interface compliance
+	var _ Interface = s
+
+	s.Add(items...)
+	return s
+}
+
+// bitItem returns item as a bit index, ok is false if item cannot be a member
+// of a bit set.
+func bitItem(item interface{}) (i int, ok bool) {
+	i, ok = item.(int)
+	return i, ok && i >= 0 && i <= MaxBitItem
+}
+
+// mustBitItem returns item as a bit index and panics if it cannot be a member
+// of a bit set.
+func mustBitItem(item interface{}) int {
+	i, ok := bitItem(item)
+	if !ok {
+		panic(fmt.Errorf("set: bit set item %v of type %T is not an int from 0 to MaxBitItem", item, item))
+	}
+	return i
+}
+
+// accepts reports whether item can be a member of s.
+func (s *bitSet) accepts(item interface{}) bool {
+	_, ok := bitItem(item)
+	return ok
+}
+
+// bits returns s, it identifies bit sets for the word-parallel operations.
+func (s *bitSet) bits() *bitSet {
+	return s
+}
+
+// set sets bit i, growing words if needed.
+func (s *bitSet) set(i int) {
+	w := i >> 6
+	if w >= len(s.words) {
+		s.words = append(s.words, make([]uint64, w+1-len(s.words))...)
+	}
+
+	if s.words[w]&(1<<(i&63)) == 0 {
+		s.words[w] |= 1 << (i & 63)
+		s.n++
+	}
+}
+
+// clear clears bit i.
+func (s *bitSet) clear(i int) {
+	w := i >> 6
+	if w < len(s.words) && s.words[w]&(1<<(i&63)) != 0 {
+		s.words[w] &^= 1 << (i & 63)
+		s.n--
+	}
+}
+
+// test reports whether bit i is set.
+func (s *bitSet) test(i int) bool {
+	w := i >> 6
+	return w < len(s.words) && s.words[w]&(1<<(i&63)) != 0
+}
+
+// setWords replaces the words of s and recounts its items.
+func (s *bitSet) setWords(words []uint64) {
+	for len(words) > 0 && words[len(words)-1] == 0 {
+		words = words[:len(words)-1]
+	}
+
+	s.words = words
+	s.n = 0
+	for _, w := range words {
+		s.n += bits.OnesCount64(w)
+	}
+}
+
+// New creates and initalizes a new BitSetNonTS.
+func (s *bitSet) New(items ...interface{}) Interface {
+	return NewBitSetNonTS(items...)
+}
+
+// Add includes the specified items (one or more) to the set. It panics if
+// one of the items is not an int from 0 to MaxBitItem, after adding the items
+// before it.
+func (s *bitSet) Add(items ...interface{}) {
+	for _, item := range items {
+		s.set(mustBitItem(item))
+	}
+}
+
+// AddInt includes the specified ints (one or more) to the set. It panics if
+// one of them is negative or larger than MaxBitItem.
+func (s *bitSet) AddInt(items ...int) {
+	for _, i := range items {
+		if i < 0 || i > MaxBitItem {
+			mustBitItem(i)
+		}
+		s.set(i)
+	}
+}
+
+// Remove deletes the specified items from the set.
+func (s *bitSet) Remove(items ...interface{}) {
+	for _, item := range items {
+		if i, ok := bitItem(item); ok {
+			s.clear(i)
+		}
+	}
+}
+
+// RemoveInt deletes the specified ints from the set.
+func (s *bitSet) RemoveInt(items ...int) {
+	for _, i := range items {
+		if i >= 0 {
+			s.clear(i)
+		}
+	}
+}
+
+// Pop deletes and return the smallest item of the set. If set is empty, nil
+// is returned.
+func (s *bitSet) Pop() interface{} {
+	i, ok := s.NextSet(0)
+	if !ok {
+		return nil
+	}
+
+	s.clear(i)
+	return i
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (s *bitSet) Has(items ...interface{}) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	for _, item := range items {
+		if i, ok := bitItem(item); !ok || !s.test(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasInt is Has for ints.
+func (s *bitSet) HasInt(items ...int) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	for _, i := range items {
+		if i < 0 || !s.test(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// NextSet returns the smallest item of the set which is not less than i. ok
+// is false if there is none.
+func (s *bitSet) NextSet(i int) (next int, ok bool) {
+	if i < 0 {
+		i = 0
+	}
+
+	w := i >> 6
+	if w >= len(s.words) {
+		return 0, false
+	}
+
+	// bits below i in its word are masked off
+	if word := s.words[w] >> (i & 63); word != 0 {
+		return i + bits.TrailingZeros64(word), true
+	}
+
+	for w++; w < len(s.words); w++ {
+		if s.words[w] != 0 {
+			return w<<6 + bits.TrailingZeros64(s.words[w]), true
+		}
+	}
+	return 0, false
+}
+
+// Size returns the number of items in a set.
+func (s *bitSet) Size() int {
+	return s.n
+}
+
+// Cardinality returns the number of items in a set, it is the same as Size.
+func (s *bitSet) Cardinality() int {
+	return s.n
+}
+
+// Clear removes all items from the set.
+func (s *bitSet) Clear() {
+	s.words = nil
+	s.n = 0
+}
+
+// IsEmpty reports whether the set is empty.
+func (s *bitSet) IsEmpty() bool {
+	return s.n == 0
+}
+
+// IsEqual test whether s and t are the same in size and have the same items.
+func (s *bitSet) IsEqual(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isEqual(s, view(t))
+}
+
+// IsSubset tests whether t is a subset of s.
+func (s *bitSet) IsSubset(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isSubset(s, view(t))
+}
+
+// IsSuperset tests whether t is a superset of s.
+func (s *bitSet) IsSuperset(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isSubset(view(t), s)
+}
+
+// Each traverses the items in the set in ascending order, calling the
+// provided function for each set member. Traversal will continue until all
+// items in the set have been visited, or if the closure returns false.
+func (s *bitSet) Each(f func(item interface{}) bool) {
+	for w, word := range s.words {
+		for word != 0 {
+			if !f(w<<6 + bits.TrailingZeros64(word)) {
+				return
+			}
+			word &= word - 1
+		}
+	}
+}
+
+// All returns an iterator over the items of the set in ascending order.
+func (s *bitSet) All() iter.Seq[interface{}] {
+	return func(yield func(item interface{}) bool) {
+		s.Each(yield)
+	}
+}
+
+// String returns a string representation of s
+func (s *bitSet) String() string {
+	return formatItems(s.Ints())
+}
+
+// List returns a slice of all items in ascending order.
+func (s *bitSet) List() []interface{} {
+	list := make([]interface{}, 0, s.n)
+	s.Each(func(item interface{}) bool {
+		list = append(list, item)
+		return true
+	})
+	return list
+}
+
+// Ints returns a slice of all items in ascending order.
+func (s *bitSet) Ints() []int {
+	ints := make([]int, 0, s.n)
+	for w, word := range s.words {
+		for word != 0 {
+			ints = append(ints, w<<6+bits.TrailingZeros64(word))
+			word &= word - 1
+		}
+	}
+	return ints
+}
+
+// Copy returns a new BitSetNonTS with a copy of s.
+func (s *bitSet) Copy() Interface {
+	c := NewBitSetNonTS()
+	c.words = append([]uint64(nil), s.words...)
+	c.n = s.n
+	return c
+}
+
+// Merge is like Union, however it modifies the current set it's applied on
+// with the given t set.
+func (s *bitSet) Merge(t Interface) {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	s.merge(view(t))
+}
+
+func (s *bitSet) merge(t Interface) {
+	if b, ok := t.(interface{ bits() *bitSet }); ok {
+		s.setWords(unionWords([]*bitSet{s, b.bits()}))
+		return
+	}
+	s.Add(t.List()...)
+}
+
+// Separate removes the set items containing in t from set s. Please aware that
+// it's not the opposite of Merge.
+func (s *bitSet) Separate(t Interface) {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	s.separate(view(t))
+}
+
+func (s *bitSet) separate(t Interface) {
+	if b, ok := t.(interface{ bits() *bitSet }); ok {
+		s.setWords(differenceWords([]*bitSet{s, b.bits()}))
+		return
+	}
+	s.Remove(t.List()...)
+}
+
+// New creates and initalizes a new BitSet.
+func (s *BitSet) New(items ...interface{}) Interface {
+	return NewBitSet(items...)
+}
+
+// Add includes the specified items (one or more) to the set. It panics if
+// one of the items is not an int from 0 to MaxBitItem, after adding the items
+// before it.
+func (s *BitSet) Add(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.bitSet.Add(items...)
+}
+
+// AddInt includes the specified ints (one or more) to the set. It panics if
+// one of them is negative or larger than MaxBitItem.
+func (s *BitSet) AddInt(items ...int) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.bitSet.AddInt(items...)
+}
+
+// Remove deletes the specified items from the set.
+func (s *BitSet) Remove(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.bitSet.Remove(items...)
+}
+
+// RemoveInt deletes the specified ints from the set.
+func (s *BitSet) RemoveInt(items ...int) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.bitSet.RemoveInt(items...)
+}
+
+// Pop deletes and return the smallest item of the set. If set is empty, nil
+// is returned.
+func (s *BitSet) Pop() interface{} {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return s.bitSet.Pop()
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (s *BitSet) Has(items ...interface{}) bool {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.bitSet.Has(items...)
+}
+
+// HasInt is Has for ints.
+func (s *BitSet) HasInt(items ...int) bool {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.bitSet.HasInt(items...)
+}
+
+// NextSet returns the smallest item of the set which is not less than i. ok
+// is false if there is none.
+func (s *BitSet) NextSet(i int) (next int, ok bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.bitSet.NextSet(i)
+}
+
+// Size returns the number of items in a set.
+func (s *BitSet) Size() int {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.n
+}
+
+// Cardinality returns the number of items in a set, it is the same as Size.
+func (s *BitSet) Cardinality() int {
+	return s.Size()
+}
+
+// Clear removes all items from the set.
+func (s *BitSet) Clear() {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.bitSet.Clear()
+}
+
+// IsEmpty reports whether the set is empty.
+func (s *BitSet) IsEmpty() bool {
+	return s.Size() == 0
+}
+
+// IsEqual test whether s and t are the same in size and have the same items.
+func (s *BitSet) IsEqual(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isEqual(&s.bitSet, view(t))
+}
+
+// IsSubset tests whether t is a subset of s.
+func (s *BitSet) IsSubset(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isSubset(&s.bitSet, view(t))
+}
+
+// IsSuperset tests whether t is a superset of s.
+func (s *BitSet) IsSuperset(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isSubset(view(t), &s.bitSet)
+}
+
+// Each traverses the items in the set in ascending order, calling the
+// provided function for each set member. Traversal will continue until all
+// items in the set have been visited, or if the closure returns false.
+func (s *BitSet) Each(f func(item interface{}) bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	s.bitSet.Each(f)
+}
+
+// All returns an iterator over the items of the set in ascending order. As
+// with Each, s is read locked from the start of the loop until it ends, so
+// the loop body must not modify s.
+func (s *BitSet) All() iter.Seq[interface{}] {
+	return func(yield func(item interface{}) bool) {
+		s.Each(yield)
+	}
+}
+
+// String returns a string representation of s
+func (s *BitSet) String() string {
+	return formatItems(s.Ints())
+}
+
+// List returns a slice of all items in ascending order.
+func (s *BitSet) List() []interface{} {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.bitSet.List()
+}
+
+// Ints returns a slice of all items in ascending order.
+func (s *BitSet) Ints() []int {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.bitSet.Ints()
+}
+
+// Copy returns a new BitSet with a copy of s.
+func (s *BitSet) Copy() Interface {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	c := NewBitSet()
+	c.words = append([]uint64(nil), s.words...)
+	c.n = s.n
+	return c
+}
+
+// Merge is like Union, however it modifies the current set it's applied on
+// with the given t set.
+func (s *BitSet) Merge(t Interface) {
+	unlock := lockSets(s, t)
+	defer unlock()
+
+	s.bitSet.merge(view(t))
+}
+
+// Separate removes the set items containing in t from set s. Please aware that
+// it's not the opposite of Merge.
+func (s *BitSet) Separate(t Interface) {
+	unlock := lockSets(s, t)
+	defer unlock()
+
+	s.bitSet.separate(view(t))
+}
+
+// unlocked returns the underlying bit set of s, which does not lock.
+func (s *BitSet) unlocked() Interface {
+	return &s.bitSet
+}
+
+// bitSetsOf returns the bit sets behind sets, ok is false unless all of them
+// are bit sets. The sets must be locked.
+func bitSetsOf(sets []Interface) (bs []*bitSet, ok bool) {
+	bs = make([]*bitSet, len(sets))
+	for i, set := range sets {
+		b, ok := view(set).(interface{ bits() *bitSet })
+		if !ok {
+			return nil, false
+		}
+		bs[i] = b.bits()
+	}
+	return bs, true
+}
+
+// newBitsLike returns set.New() with its words replaced by words.
+func newBitsLike(set Interface, words []uint64) Interface {
+	n := set.New()
+	view(n).(interface{ bits() *bitSet }).bits().setWords(words)
+	return n
+}
+
+// unionWords returns the words of the union of bs.
+func unionWords(bs []*bitSet) []uint64 {
+	size := 0
+	for _, b := range bs {
+		size = max(size, len(b.words))
+	}
+
+	words := make([]uint64, size)
+	for _, b := range bs {
+		for i, w := range b.words {
+			words[i] |= w
+		}
+	}
+	return words
+}
+
+// intersectionWords returns the words of the intersection of bs.
+func intersectionWords(bs []*bitSet) []uint64 {
+	size := len(bs[0].words)
+	for _, b := range bs[1:] {
+		size = min(size, len(b.words))
+	}
+
+	words := append([]uint64(nil), bs[0].words[:size]...)
+	for _, b := range bs[1:] {
+		for i := range words {
+			words[i] &= b.words[i]
+		}
+	}
+	return words
+}
+
+// differenceWords returns the words of bs[0] without the items of bs[1:].
+func differenceWords(bs []*bitSet) []uint64 {
+	words := append([]uint64(nil), bs[0].words...)
+	for _, b := range bs[1:] {
+		for i := 0; i < len(words) && i < len(b.words); i++ {
+			words[i] &^= b.words[i]
+		}
+	}
+	return words
+}
@@ -0,0 +1,186 @@
+package set
+
+import (
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestBitSet_Add(t *testing.T) {
+	for _, s := range []Interface{NewBitSet(), NewBitSetNonTS()} {
+		s.Add(3, 200, 3, 64)
+
+		if s.Size() != 3 || !s.Has(3, 64, 200) || s.Has(4) || s.Has("3") || s.Has(-1) {
+			t.Error("Add: wrong items", s)
+		}
+
+		if !reflect.DeepEqual(IntSlice(s), []int{3, 64, 200}) {
+			t.Error("IntSlice: should return the items in ascending order, got", IntSlice(s))
+		}
+
+		s.Remove(64, 1000, "x")
+		if s.Size() != 2 || s.Has(64) {
+			t.Error("Remove: wrong items", s)
+		}
+
+		if item := s.Pop(); item != 3 || s.Size() != 1 {
+			t.Error("Pop: should remove the smallest item, got", item)
+		}
+	}
+}
+
+func TestBitSet_Add_Panics(t *testing.T) {
+	for _, item := range []interface{}{-1, "1", int64(1)} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Add: should panic for %v of type %T", item, item)
+				}
+			}()
+			NewBitSet(item)
+		}()
+	}
+
+	if math.MaxInt == MaxBitItem {
+		return // 32-bit ints cannot be larger
+	}
+	for _, add := range []func(s *BitSetNonTS){
+		func(s *BitSetNonTS) { s.Add(math.MaxInt) },
+		func(s *BitSetNonTS) { s.AddInt(math.MaxInt) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("Add: should panic past MaxBitItem instead of allocating")
+				}
+			}()
+			add(NewBitSetNonTS())
+		}()
+	}
+}
+
+func TestBitSet_Ints(t *testing.T) {
+	s := NewBitSet()
+	s.AddInt(5, 130, 63, 64)
+
+	if s.Cardinality() != 4 || !s.HasInt(5, 63, 64, 130) || s.HasInt(5, 6) || s.HasInt() {
+		t.Error("AddInt: wrong items", s)
+	}
+
+	if s.String() != "[5, 63, 64, 130]" {
+		t.Error("String: got", s)
+	}
+
+	var got []int
+	for i, ok := s.NextSet(0); ok; i, ok = s.NextSet(i + 1) {
+		got = append(got, i)
+	}
+	if !reflect.DeepEqual(got, []int{5, 63, 64, 130}) {
+		t.Error("NextSet: should visit all items in order, got", got)
+	}
+
+	if _, ok := s.NextSet(131); ok {
+		t.Error("NextSet: should report no item past the last one")
+	}
+
+	s.RemoveInt(63, -5)
+	if next, _ := s.NextSet(6); next != 64 {
+		t.Error("NextSet: should skip removed items, got", next)
+	}
+}
+
+func TestBitSet_Operations(t *testing.T) {
+	a := NewBitSet(1, 2, 3, 100, 1000)
+	b := NewBitSetNonTS(2, 3, 4, 1000)
+	c := NewBitSet(3, 1000, 5000)
+
+	if u := Union(a, b, c); !u.IsEqual(New(1, 2, 3, 4, 100, 1000, 5000)) {
+		t.Error("Union: wrong items", u)
+	} else if _, ok := u.(*BitSet); !ok {
+		t.Errorf("Union: should return a *BitSet, got %T", u)
+	}
+
+	if i := Intersection(a, b, c); !i.IsEqual(New(3, 1000)) {
+		t.Error("Intersection: wrong items", i)
+	}
+
+	if d := Difference(a, b, c); !d.IsEqual(New(1, 100)) || d.Size() != 2 {
+		t.Error("Difference: wrong items", d)
+	}
+
+	// mixed with a map based set the generic path is used, and a union with
+	// items a bit set cannot hold is a plain set
+	for _, u := range []Interface{Union(a, New("x")), SymmetricDifference(a, New(1, "x"))} {
+		if !u.Has("x", 2) {
+			t.Error("Union: wrong items", u)
+		} else if _, ok := u.(*SetNonTS); !ok {
+			t.Errorf("Union: should return a *SetNonTS, got %T", u)
+		}
+	}
+	if d := Difference(a, New(1, "x")); !d.IsEqual(New(2, 3, 100, 1000)) {
+		t.Error("Difference: wrong items", d)
+	}
+
+	a.Merge(c)
+	a.Separate(b)
+	if !a.IsEqual(New(1, 100, 5000)) || a.Cardinality() != 3 {
+		t.Error("Merge: wrong items after Merge and Separate", a)
+	}
+
+	a.Merge(NewNonTS(7))
+	if !a.Has(7) || !a.Copy().IsEqual(a) || !a.IsSubset(New(7)) {
+		t.Error("Merge: should accept other sets", a)
+	}
+}
+
+func TestBitSet_Race(t *testing.T) {
+	s := NewBitSet()
+	u := NewBitSet()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				s.AddInt(g*200 + i)
+				u.Merge(s)
+				Intersection(s, u)
+				s.NextSet(i)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if s.Size() != 1600 || !u.IsEqual(s) {
+		t.Error("Race: wrong size", s.Size())
+	}
+}
+
+func BenchmarkBitSet_Add(b *testing.B) {
+	s := NewBitSetNonTS()
+	for i := 0; i < b.N; i++ {
+		s.AddInt(i & 0xfffff)
+	}
+}
+
+func BenchmarkSetNonTS_AddInt(b *testing.B) {
+	s := NewNonTS()
+	for i := 0; i < b.N; i++ {
+		s.Add(i & 0xfffff)
+	}
+}
+
+func BenchmarkBitSet_Intersection(b *testing.B) {
+	x, y := NewBitSetNonTS(), NewBitSetNonTS()
+	for i := 0; i < 1<<16; i++ {
+		x.AddInt(i * 2)
+		y.AddInt(i * 3)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Intersection(x, y)
+	}
+}
@@ -0,0 +1,243 @@
+package set
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// bloomVersion is the version of the binary encoding written by
+// BloomFilter.MarshalBinary: the version byte, the number of hash functions
+// and the number of bits as uvarints, then the bits as little endian uint64
+// words.
+const bloomVersion = 1
+
+// BloomFilter is a probabilistic set which answers Has with no false
+// negatives and a bounded rate of false positives, in a fraction of the
+// memory of a set. Items cannot be listed or removed. Items are hashed the
+// same way in every process, so marshalled filters can be shared. Items of
+// any type are told apart by type and value as in a set, comparing struct
+// fields and array elements, and pointers and channels by their address,
+// which is only meaningful within one process.
+//
+// It is safe for concurrent use.
+type BloomFilter struct {
+	words []uint64
+	m     uint64 // number of bits
+	k     int    // number of hash functions
+	setLock
+}
+
+// NewBloomFilter creates a BloomFilter sized for n items with a false positive
+// rate of fp, which must be between 0 and 1.
+func NewBloomFilter(n int, fp float64) *BloomFilter {
+	if !(fp > 0 && fp < 1) {
+		panic(fmt.Errorf("set: Bloom filter false positive rate %v is not between 0 and 1", fp))
+	}
+	n = max(n, 1)
+
+	m := math.Ceil(-float64(n) * math.Log(fp) / (math.Ln2 * math.Ln2))
+	words := (uint64(m) + 63) / 64
+	k := max(1, int(math.Round(float64(words*64)/float64(n)*math.Ln2)))
+
+	return &BloomFilter{words: make([]uint64, words), m: words * 64, k: k}
+}
+
+// NewBloomFilterFrom creates a BloomFilter with the items of s, sized for the
+// number of items of s and a false positive rate of fp.
+func NewBloomFilterFrom(s Interface, fp float64) *BloomFilter {
+	unlock := lockSets(nil, s)
+	defer unlock()
+
+	v := view(s)
+	f := NewBloomFilter(v.Size(), fp)
+	v.Each(func(item interface{}) bool {
+		f.add(hashItem(item))
+		return true
+	})
+	return f
+}
+
+// locations calls f with the bit index of every hash function for the item
+// hash h, until f returns false. The indexes are derived from h by double
+// hashing.
+func (f *BloomFilter) locations(h uint64, fn func(i uint64) bool) bool {
+	h2 := mix64(h^0x9e3779b97f4a7c15) | 1
+	for i := 0; i < f.k; i++ {
+		if !fn(h % f.m) {
+			return false
+		}
+		h += h2
+	}
+	return true
+}
+
+func (f *BloomFilter) add(h uint64) {
+	f.locations(h, func(i uint64) bool {
+		f.words[i/64] |= 1 << (i % 64)
+		return true
+	})
+}
+
+func (f *BloomFilter) has(h uint64) bool {
+	return f.locations(h, func(i uint64) bool {
+		return f.words[i/64]&(1<<(i%64)) != 0
+	})
+}
+
+// Add includes the specified items (one or more) to the filter.
+func (f *BloomFilter) Add(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	for _, item := range items {
+		f.add(hashItem(item))
+	}
+}
+
+// Has reports whether the items passed may have been added. It returns false
+// if nothing is passed. For multiple items it returns true only if all of
+// them may have been added. False means an item was definitely not added.
+func (f *BloomFilter) Has(items ...interface{}) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	f.l.RLock()
+	defer f.l.RUnlock()
+
+	for _, item := range items {
+		if !f.has(hashItem(item)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Clear removes all items from the filter.
+func (f *BloomFilter) Clear() {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	clear(f.words)
+}
+
+// Merge adds the items of t to f, afterwards f is the filter of the union of
+// both. It returns ErrIncompatible unless both were created with the same
+// parameters.
+func (f *BloomFilter) Merge(t *BloomFilter) error {
+	unlock := lockSets(f, t)
+	defer unlock()
+
+	if f.m != t.m || f.k != t.k {
+		return ErrIncompatible
+	}
+
+	for i, w := range t.words {
+		f.words[i] |= w
+	}
+	return nil
+}
+
+// estimate returns the estimated number of items of a filter like f with
+// ones set bits.
+func (f *BloomFilter) estimate(ones int) float64 {
+	m := float64(f.m)
+	return -m / float64(f.k) * math.Log(1-float64(ones)/m)
+}
+
+func (f *BloomFilter) ones() int {
+	n := 0
+	for _, w := range f.words {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// EstimatedSize returns an estimate of the number of distinct items added to
+// the filter. It is +Inf if all bits are set.
+func (f *BloomFilter) EstimatedSize() float64 {
+	f.l.RLock()
+	defer f.l.RUnlock()
+
+	return f.estimate(f.ones())
+}
+
+// EstimatedIntersection returns an estimate of the number of distinct items
+// added to both f and t, from the sizes of both and of their union. It
+// returns ErrIncompatible unless both were created with the same parameters.
+func (f *BloomFilter) EstimatedIntersection(t *BloomFilter) (float64, error) {
+	unlock := lockSets(nil, f, t)
+	defer unlock()
+
+	if f.m != t.m || f.k != t.k {
+		return 0, ErrIncompatible
+	}
+
+	union := 0
+	for i, w := range f.words {
+		union += bits.OnesCount64(w | t.words[i])
+	}
+
+	n := f.estimate(f.ones()) + f.estimate(t.ones()) - f.estimate(union)
+	return max(n, 0), nil
+}
+
+// FalsePositiveRate returns the probability that Has reports true for an item
+// which was not added, given the items added so far.
+func (f *BloomFilter) FalsePositiveRate() float64 {
+	f.l.RLock()
+	defer f.l.RUnlock()
+
+	return math.Pow(float64(f.ones())/float64(f.m), float64(f.k))
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (f *BloomFilter) MarshalBinary() ([]byte, error) {
+	f.l.RLock()
+	defer f.l.RUnlock()
+
+	buf := make([]byte, 0, 1+2*binary.MaxVarintLen64+8*len(f.words))
+	buf = append(buf, bloomVersion)
+	buf = binary.AppendUvarint(buf, uint64(f.k))
+	buf = binary.AppendUvarint(buf, f.m)
+	for _, w := range f.words {
+		buf = binary.LittleEndian.AppendUint64(buf, w)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It replaces f with
+// the decoded filter, including its parameters. On error f is unchanged.
+func (f *BloomFilter) UnmarshalBinary(data []byte) error {
+	r := &binaryReader{data: data}
+	if v := r.byte(); r.err == nil && v != bloomVersion {
+		return fmt.Errorf("set: unsupported Bloom filter encoding version %d", v)
+	}
+
+	k, m := r.uvarint(), r.uvarint()
+	switch {
+	case r.err != nil:
+		return r.err
+	case k == 0 || k > 1024 || m == 0 || m%64 != 0:
+		return fmt.Errorf("set: invalid Bloom filter with %d hash functions and %d bits", k, m)
+	case m/8 != uint64(len(r.data)):
+		return fmt.Errorf("set: Bloom filter of %d bits has %d bytes", m, len(r.data))
+	}
+
+	words := make([]uint64, m/64)
+	for i := range words {
+		words[i] = r.uint64()
+	}
+
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	f.words, f.m, f.k = words, m, int(k)
+	return nil
+}
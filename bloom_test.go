@@ -0,0 +1,173 @@
+package set
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestBloomFilter_Has(t *testing.T) {
+	f := NewBloomFilter(10000, 0.01)
+	for i := 0; i < 10000; i++ {
+		f.Add(i)
+	}
+
+	for i := 0; i < 10000; i++ {
+		if !f.Has(i) {
+			t.Fatal("Has: false negative for", i)
+		}
+	}
+
+	fp := 0
+	for i := 10000; i < 110000; i++ {
+		if f.Has(i) {
+			fp++
+		}
+	}
+	if rate := float64(fp) / 100000; rate > 0.015 {
+		t.Error("Has: false positive rate should be about 0.01, got", rate)
+	}
+
+	if rate := f.FalsePositiveRate(); math.Abs(rate-0.01) > 0.003 {
+		t.Error("FalsePositiveRate: should be about 0.01, got", rate)
+	}
+
+	if f.Has() || f.Has(1, 1<<30) || f.Has(int64(1)) {
+		t.Error("Has: should report false for nothing and for items not added")
+	}
+
+	f.Clear()
+	if f.Has(1) || f.EstimatedSize() != 0 {
+		t.Error("Clear: should remove all items")
+	}
+}
+
+func TestBloomFilter_ItemIdentity(t *testing.T) {
+	type node struct{ N int }
+	p, negZero := &node{N: 1}, math.Copysign(0, -1)
+
+	f := NewBloomFilter(1000, 0.0001)
+	f.Add(p, 0.0, complex(negZero, 1))
+	p.N = 2
+
+	if !f.Has(p) {
+		t.Error("Has: a pointer should be found after the value it points to changed")
+	}
+	if f.Has(&node{N: 1}) {
+		t.Error("Has: a pointer should not match another pointer to an equal value")
+	}
+	if !f.Has(negZero) || !f.Has(complex(0, 1)) {
+		t.Error("Has: -0.0 and 0.0 should be the same item, as in a set")
+	}
+
+	// composite items equal in a set, with zeros in fields and interfaces
+	type point struct {
+		X, Y float64
+		Tag  interface{}
+	}
+	f.Add(point{0, 1, 0.0}, [2]complex64{0, 1})
+	for _, item := range []interface{}{point{negZero, 1, negZero}, [2]complex64{complex(float32(negZero), 0), 1}} {
+		if !New(point{0, 1, 0.0}, [2]complex64{0, 1}).Has(item) {
+			t.Fatal("Has: the set should have", item)
+		}
+		if !f.Has(item) {
+			t.Errorf("Has: false negative for %#v, which is equal to an added item", item)
+		}
+	}
+	if f.Has(point{0, 1, 0}) || f.Has(point{0, 1, nil}) {
+		t.Error("Has: fields of different types or values should differ")
+	}
+}
+
+func TestBloomFilter_Estimates(t *testing.T) {
+	a := NewBloomFilter(20000, 0.01)
+	b := NewBloomFilter(20000, 0.01)
+	for i := 0; i < 10000; i++ {
+		a.Add(i)
+		b.Add(i + 6000)
+	}
+
+	if n := a.EstimatedSize(); math.Abs(n-10000) > 300 {
+		t.Error("EstimatedSize: should be about 10000, got", n)
+	}
+
+	if n, err := a.EstimatedIntersection(b); err != nil || math.Abs(n-4000) > 400 {
+		t.Error("EstimatedIntersection: should be about 4000, got", n, err)
+	}
+
+	if err := a.Merge(b); err != nil || !a.Has(15999) || math.Abs(a.EstimatedSize()-16000) > 500 {
+		t.Error("Merge: should add the items of the other filter", err)
+	}
+
+	other := NewBloomFilter(100, 0.01)
+	if err := a.Merge(other); !errors.Is(err, ErrIncompatible) {
+		t.Error("Merge: should fail for other parameters, got", err)
+	}
+	if _, err := a.EstimatedIntersection(other); !errors.Is(err, ErrIncompatible) {
+		t.Error("EstimatedIntersection: should fail for other parameters, got", err)
+	}
+}
+
+func TestBloomFilter_From(t *testing.T) {
+	s := New("istanbul", "ankara", 42, 3.14)
+	f := NewBloomFilterFrom(s, 0.001)
+
+	if !f.Has(s.List()...) || f.Has("izmir") {
+		t.Error("NewBloomFilterFrom: should hold the items of the set")
+	}
+}
+
+func TestBloomFilter_MarshalBinary(t *testing.T) {
+	f := NewBloomFilter(1000, 0.05)
+	f.Add("a", "b", 1, 2.5)
+
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewBloomFilter(1, 0.5)
+	if err := g.UnmarshalBinary(data); err != nil || !g.Has("a", "b", 1, 2.5) || g.Merge(f) != nil {
+		t.Error("UnmarshalBinary: should restore the filter", err)
+	}
+
+	for n := 0; n < len(data); n++ {
+		if err := g.UnmarshalBinary(data[:n]); err == nil {
+			t.Fatalf("UnmarshalBinary: should fail for %d of %d bytes", n, len(data))
+		}
+	}
+}
+
+func TestBloomFilter_Race(t *testing.T) {
+	a := NewBloomFilter(1000, 0.01)
+	b := NewBloomFilter(1000, 0.01)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				a.Add(g*100 + i)
+				b.Merge(a)
+				a.EstimatedIntersection(b)
+				a.Merge(b)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	for i := 0; i < 400; i++ {
+		if !b.Has(i) {
+			t.Fatal("Merge: lost item", i)
+		}
+	}
+}
+
+func BenchmarkBloomFilter_Add(b *testing.B) {
+	f := NewBloomFilter(b.N, 0.01)
+	for i := 0; i < b.N; i++ {
+		f.Add(i)
+	}
+}
@@ -0,0 +1,317 @@
+package set
+
+import (
+	"iter"
+	"maps"
+	"sync/atomic"
+)
+
+// GSet is a grow-only set, the simplest conflict-free replicated data type
+// (CRDT). Items can be added but never removed. Merge is commutative,
+// associative and idempotent, so replicas which merge each other's states in
+// any order, any number of times, end up with the same items.
+//
+// It is safe for concurrent use.
+type GSet struct {
+	s set
+	setLock
+	shared atomic.Bool // s.m is shared with a view, see Value
+}
+
+// NewGSet creates and initialize a new GSet. It accepts a variable number of
+// arguments to populate the initial set.
+func NewGSet(items ...interface{}) *GSet {
+	g := &GSet{}
+	g.s.m = make(map[interface{}]struct{})
+
+	g.s.add(items)
+	return g
+}
+
+// own copies the items of g if they are shared with a view, so that g can be
+// modified. g must be write locked.
+func (g *GSet) own() {
+	if g.shared.Load() {
+		g.s.m = maps.Clone(g.s.m)
+		g.shared.Store(false)
+	}
+}
+
+// Add includes the specified items (one or more) to the set.
+func (g *GSet) Add(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	g.l.Lock()
+	defer g.l.Unlock()
+
+	g.own()
+	g.s.add(items)
+}
+
+// Merge adds the items of t to g, afterwards g holds the items of both.
+func (g *GSet) Merge(t *GSet) {
+	unlock := lockSets(g, t)
+	defer unlock()
+
+	g.own()
+	for item := range t.s.m {
+		g.s.m[item] = keyExists
+	}
+}
+
+// Copy returns a new GSet with a copy of g.
+func (g *GSet) Copy() *GSet {
+	g.l.RLock()
+	defer g.l.RUnlock()
+
+	c := NewGSet()
+	c.s.m = maps.Clone(g.s.m)
+	return c
+}
+
+// Value returns a read-only view of the items of g at this moment, which
+// implements Interface. It takes constant time: g and the view share their
+// items until g is next modified, which copies them once.
+func (g *GSet) Value() *SetSnapshot {
+	g.l.RLock()
+	defer g.l.RUnlock()
+
+	g.shared.Store(true)
+	return &SetSnapshot{s: set{m: g.s.m}}
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (g *GSet) Has(items ...interface{}) bool {
+	g.l.RLock()
+	defer g.l.RUnlock()
+
+	return g.s.Has(items...)
+}
+
+// Size returns the number of items in the set.
+func (g *GSet) Size() int {
+	g.l.RLock()
+	defer g.l.RUnlock()
+
+	return g.s.Size()
+}
+
+// IsEmpty reports whether the set is empty.
+func (g *GSet) IsEmpty() bool {
+	return g.Size() == 0
+}
+
+// Each traverses the items in the set, calling the provided function for each
+// set member. Traversal will continue until all items in the set have been
+// visited, or if the closure returns false. g is read locked during the
+// traversal, so the closure must not modify g.
+func (g *GSet) Each(f func(item interface{}) bool) {
+	g.l.RLock()
+	defer g.l.RUnlock()
+
+	g.s.Each(f)
+}
+
+// All returns an iterator over the items of the set, visiting them in the
+// same order as Each. As with Each, g is read locked from the start of the
+// loop until it ends, so the loop body must not modify g.
+func (g *GSet) All() iter.Seq[interface{}] {
+	return func(yield func(item interface{}) bool) {
+		g.Each(yield)
+	}
+}
+
+// List returns a slice of all items.
+func (g *GSet) List() []interface{} {
+	g.l.RLock()
+	defer g.l.RUnlock()
+
+	return g.s.List()
+}
+
+// String returns a string representation of g
+func (g *GSet) String() string {
+	g.l.RLock()
+	defer g.l.RUnlock()
+
+	return g.s.String()
+}
+
+// TwoPhaseSet is a set CRDT which supports removing items, made of two
+// grow-only sets: the items ever added and the removed ones, the tombstones.
+// An item is in the set if it was added and not removed. Merge is
+// commutative, associative and idempotent, so replicas which merge each
+// other's states in any order, any number of times, end up with the same
+// items.
+//
+// Removing an item is permanent: it cannot be added again, on any replica,
+// and its tombstone is kept forever. Use ORSet for items which come back.
+//
+// It is safe for concurrent use.
+type TwoPhaseSet struct {
+	added   set
+	removed set // always a subset of added
+	setLock
+}
+
+// NewTwoPhaseSet creates and initialize a new TwoPhaseSet. It accepts a
+// variable number of arguments to populate the initial set.
+func NewTwoPhaseSet(items ...interface{}) *TwoPhaseSet {
+	p := &TwoPhaseSet{}
+	p.added.m = make(map[interface{}]struct{})
+	p.removed.m = make(map[interface{}]struct{})
+
+	p.added.add(items)
+	return p
+}
+
+// Add includes the specified items (one or more) to the set. Items which
+// were removed before are not added again.
+func (p *TwoPhaseSet) Add(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	p.added.add(items)
+}
+
+// Remove deletes the specified items from the set, for good. Items which are
+// not in the set are ignored, so they can still be added later.
+func (p *TwoPhaseSet) Remove(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	for _, item := range items {
+		if p.added.Has(item) {
+			p.removed.m[item] = keyExists
+		}
+	}
+}
+
+// Merge adds the added and removed items of t to p, afterwards p holds the
+// items of both which neither removed.
+func (p *TwoPhaseSet) Merge(t *TwoPhaseSet) {
+	unlock := lockSets(p, t)
+	defer unlock()
+
+	for item := range t.added.m {
+		p.added.m[item] = keyExists
+	}
+	for item := range t.removed.m {
+		p.removed.m[item] = keyExists
+	}
+}
+
+// Copy returns a new TwoPhaseSet with a copy of p, including its tombstones.
+func (p *TwoPhaseSet) Copy() *TwoPhaseSet {
+	p.l.RLock()
+	defer p.l.RUnlock()
+
+	c := NewTwoPhaseSet()
+	c.added.m = maps.Clone(p.added.m)
+	c.removed.m = maps.Clone(p.removed.m)
+	return c
+}
+
+// Value returns a read-only copy of the items of p at this moment, which
+// implements Interface.
+func (p *TwoPhaseSet) Value() *SetSnapshot {
+	p.l.RLock()
+	defer p.l.RUnlock()
+
+	return &SetSnapshot{s: set{m: p.members()}}
+}
+
+// members returns a new map of the items of p. p must be read locked.
+func (p *TwoPhaseSet) members() map[interface{}]struct{} {
+	m := make(map[interface{}]struct{}, len(p.added.m)-len(p.removed.m))
+	for item := range p.added.m {
+		if _, ok := p.removed.m[item]; !ok {
+			m[item] = keyExists
+		}
+	}
+	return m
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (p *TwoPhaseSet) Has(items ...interface{}) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	p.l.RLock()
+	defer p.l.RUnlock()
+
+	for _, item := range items {
+		if !p.added.Has(item) || p.removed.Has(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// Size returns the number of items in the set.
+func (p *TwoPhaseSet) Size() int {
+	p.l.RLock()
+	defer p.l.RUnlock()
+
+	return len(p.added.m) - len(p.removed.m)
+}
+
+// IsEmpty reports whether the set is empty.
+func (p *TwoPhaseSet) IsEmpty() bool {
+	return p.Size() == 0
+}
+
+// Each traverses the items in the set, calling the provided function for each
+// set member. Traversal will continue until all items in the set have been
+// visited, or if the closure returns false. p is read locked during the
+// traversal, so the closure must not modify p.
+func (p *TwoPhaseSet) Each(f func(item interface{}) bool) {
+	p.l.RLock()
+	defer p.l.RUnlock()
+
+	for item := range p.added.m {
+		if _, ok := p.removed.m[item]; ok {
+			continue
+		}
+		if !f(item) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over the items of the set, visiting them in the
+// same order as Each. As with Each, p is read locked from the start of the
+// loop until it ends, so the loop body must not modify p.
+func (p *TwoPhaseSet) All() iter.Seq[interface{}] {
+	return func(yield func(item interface{}) bool) {
+		p.Each(yield)
+	}
+}
+
+// List returns a slice of all items.
+func (p *TwoPhaseSet) List() []interface{} {
+	list := make([]interface{}, 0, p.Size())
+	p.Each(func(item interface{}) bool {
+		list = append(list, item)
+		return true
+	})
+	return list
+}
+
+// String returns a string representation of p
+func (p *TwoPhaseSet) String() string {
+	return p.Value().String()
+}
@@ -0,0 +1,190 @@
+package set
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// replica is a CRDT under test, with a copy of its state to deliver to other
+// replicas.
+type replica[T any] interface {
+	Merge(t T)
+	Copy() T
+	Value() *SetSnapshot
+}
+
+// checkConvergence lets n replicas change and exchange states in random
+// order, with states delivered late, several times or to themselves, and
+// checks that all replicas end with the same items after a final exchange.
+func checkConvergence[T replica[T]](t *testing.T, seed int64, replicas []T, change func(rnd *rand.Rand, r T)) {
+	t.Helper()
+	rnd := rand.New(rand.NewSource(seed))
+
+	var inFlight []T
+	for step := 0; step < 500; step++ {
+		r := replicas[rnd.Intn(len(replicas))]
+		switch rnd.Intn(3) {
+		case 0:
+			change(rnd, r)
+		case 1:
+			inFlight = append(inFlight, r.Copy())
+		default:
+			if len(inFlight) > 0 {
+				// deliver any pending state, possibly again later
+				r.Merge(inFlight[rnd.Intn(len(inFlight))])
+			}
+		}
+	}
+
+	// a final exchange in random order and with duplicates
+	states := make([]T, 0, 2*len(replicas))
+	for _, r := range replicas {
+		states = append(states, r.Copy(), r.Copy())
+	}
+	for _, r := range replicas {
+		for _, i := range rnd.Perm(len(states)) {
+			r.Merge(states[i])
+		}
+	}
+
+	want := replicas[0].Value()
+	for i, r := range replicas {
+		if got := r.Value(); !got.IsEqual(want) {
+			t.Fatalf("Merge: seed %d replica %d has %v, want %v", seed, i, got, want)
+		}
+	}
+}
+
+// checkMergeLaws checks that Merge is commutative, associative and idempotent
+// for the states a, b and c.
+func checkMergeLaws[T replica[T]](t *testing.T, a, b, c T) {
+	t.Helper()
+	merged := func(states ...T) *SetSnapshot {
+		r := states[0].Copy()
+		for _, s := range states[1:] {
+			r.Merge(s)
+		}
+		return r.Value()
+	}
+
+	if !merged(a, b).IsEqual(merged(b, a)) {
+		t.Error("Merge: should be commutative")
+	}
+
+	ab := a.Copy()
+	ab.Merge(b)
+	bc := b.Copy()
+	bc.Merge(c)
+	if !merged(ab, c).IsEqual(merged(a, bc)) {
+		t.Error("Merge: should be associative")
+	}
+
+	if !merged(a, a).IsEqual(a.Value()) || !merged(ab, b).IsEqual(ab.Value()) {
+		t.Error("Merge: should be idempotent")
+	}
+}
+
+func TestGSet_Merge_convergence(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		replicas := []*GSet{NewGSet(), NewGSet(), NewGSet(), NewGSet()}
+		checkConvergence(t, seed, replicas, func(rnd *rand.Rand, r *GSet) {
+			r.Add(rnd.Intn(50))
+		})
+
+		rnd := rand.New(rand.NewSource(seed))
+		states := make([]*GSet, 3)
+		for i := range states {
+			states[i] = NewGSet()
+			for j := 0; j < 10; j++ {
+				states[i].Add(rnd.Intn(20))
+			}
+		}
+		checkMergeLaws(t, states[0], states[1], states[2])
+	}
+}
+
+func TestTwoPhaseSet_Merge_convergence(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		replicas := []*TwoPhaseSet{NewTwoPhaseSet(), NewTwoPhaseSet(), NewTwoPhaseSet()}
+		checkConvergence(t, seed, replicas, func(rnd *rand.Rand, r *TwoPhaseSet) {
+			if rnd.Intn(3) == 0 {
+				r.Remove(rnd.Intn(30))
+			} else {
+				r.Add(rnd.Intn(30))
+			}
+		})
+
+		rnd := rand.New(rand.NewSource(seed))
+		states := make([]*TwoPhaseSet, 3)
+		for i := range states {
+			states[i] = NewTwoPhaseSet()
+			for j := 0; j < 10; j++ {
+				states[i].Add(rnd.Intn(20))
+				states[i].Remove(rnd.Intn(20))
+			}
+		}
+		checkMergeLaws(t, states[0], states[1], states[2])
+	}
+}
+
+func TestGSet_Value(t *testing.T) {
+	g := NewGSet("a", "b")
+	v := g.Value()
+	g.Add("c")
+	g.Merge(NewGSet("d"))
+
+	if !v.IsEqual(New("a", "b")) {
+		t.Error("Value: should not change with the set, got", v)
+	}
+	if !g.Has("a", "b", "c", "d") || g.Size() != 4 || len(g.List()) != 4 {
+		t.Error("Merge: should add the items of t, got", g)
+	}
+	if !Union(g.Value(), New("e")).IsEqual(New("a", "b", "c", "d", "e")) {
+		t.Error("Value: should be usable as an Interface")
+	}
+}
+
+func TestTwoPhaseSet_Remove(t *testing.T) {
+	p := NewTwoPhaseSet("a", "b")
+	p.Remove("a", "z")
+	p.Add("a", "z")
+
+	if p.Has("a") || !p.Has("b", "z") || p.Size() != 2 {
+		t.Error("Remove: removed items should not come back, got", p)
+	}
+
+	q := NewTwoPhaseSet("b")
+	q.Remove("b")
+	p.Merge(q)
+	if p.Has("b") || !p.Value().IsEqual(New("z")) {
+		t.Error("Merge: should apply the removals of t, got", p)
+	}
+}
+
+func TestTwoPhaseSet_Race(t *testing.T) {
+	p, q := NewTwoPhaseSet(), NewTwoPhaseSet()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				p.Add(i)
+				q.Add(i + g)
+				q.Remove(i - 1)
+				p.Merge(q)
+				q.Merge(p)
+				p.Value()
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	p.Merge(q)
+	q.Merge(p)
+	if !p.Value().IsEqual(q.Value()) {
+		t.Error("Merge: replicas should converge")
+	}
+}
@@ -0,0 +1,276 @@
+package set
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrFilterFull is returned by CuckooFilter.TryAdd when an item finds no free
+// slot.
+var ErrFilterFull = errors.New("set: cuckoo filter is full")
+
+// cuckooMaxKicks is the number of fingerprints moved to make room for a new
+// one before the filter is considered full.
+const cuckooMaxKicks = 500
+
+// CuckooFilter is a probabilistic set which, unlike a BloomFilter, supports
+// Remove. It stores a short fingerprint of every item in one of two buckets.
+// Has has no false negatives for items that were added and not removed, and
+// a false positive rate of about 2*bucketSize/2^fingerprintBits.
+//
+// Add, Has, Remove and Size have the same meaning as in Interface, so code
+// using them works with a Set as well as with a CuckooFilter: adding an item
+// that may be in the filter already has no effect. The price is that items
+// with the same fingerprint and buckets cannot be told apart, so the second
+// one is not stored and removing either removes both, a rare false negative.
+// Removing an item that was never added may remove another one as well.
+//
+// It is safe for concurrent use.
+type CuckooFilter struct {
+	slots   []uint64 // fingerprints, bit-packed; 0 is an empty slot
+	fpBits  uint
+	bucket  int // slots per bucket
+	buckets uint64
+	n       int
+	rnd     uint64
+	setLock
+}
+
+// NewCuckooFilter creates a CuckooFilter sized for n items, with fingerprints
+// of fingerprintBits bits, 1 to 32, and buckets of bucketSize slots. Zero
+// selects the defaults of 16 bits and 4 slots, which allow a load of 95% at a
+// false positive rate of about 0.01%.
+func NewCuckooFilter(n, fingerprintBits, bucketSize int) *CuckooFilter {
+	if fingerprintBits == 0 {
+		fingerprintBits = 16
+	}
+	if bucketSize == 0 {
+		bucketSize = 4
+	}
+	if fingerprintBits < 1 || fingerprintBits > 32 || bucketSize < 1 {
+		panic(fmt.Errorf("set: invalid cuckoo filter with %d bit fingerprints and %d slot buckets", fingerprintBits, bucketSize))
+	}
+
+	// the load factors reached before the first failure, by bucket size
+	load := 0.95
+	switch bucketSize {
+	case 1:
+		load = 0.5
+	case 2:
+		load = 0.84
+	}
+
+	buckets := uint64(1)
+	for float64(buckets)*float64(bucketSize)*load < float64(n) {
+		buckets <<= 1
+	}
+
+	bits := buckets * uint64(bucketSize) * uint64(fingerprintBits)
+	return &CuckooFilter{
+		slots:   make([]uint64, (bits+63)/64+1),
+		fpBits:  uint(fingerprintBits),
+		bucket:  bucketSize,
+		buckets: buckets,
+		rnd:     0x9e3779b97f4a7c15,
+	}
+}
+
+// get returns the fingerprint in slot i.
+func (f *CuckooFilter) get(i uint64) uint64 {
+	pos := i * uint64(f.fpBits)
+	w, off := pos/64, pos%64
+
+	v := f.slots[w] >> off
+	if off+uint64(f.fpBits) > 64 {
+		v |= f.slots[w+1] << (64 - off)
+	}
+	return v & (1<<f.fpBits - 1)
+}
+
+// put stores the fingerprint fp in slot i.
+func (f *CuckooFilter) put(i, fp uint64) {
+	pos := i * uint64(f.fpBits)
+	w, off := pos/64, pos%64
+	mask := uint64(1)<<f.fpBits - 1
+
+	f.slots[w] = f.slots[w]&^(mask<<off) | fp<<off
+	if off+uint64(f.fpBits) > 64 {
+		f.slots[w+1] = f.slots[w+1]&^(mask>>(64-off)) | fp>>(64-off)
+	}
+}
+
+// locate returns the fingerprint of item and its two buckets.
+func (f *CuckooFilter) locate(item interface{}) (fp, i1, i2 uint64) {
+	h := hashItem(item)
+	fp = (h >> 32) & (1<<f.fpBits - 1)
+	if fp == 0 {
+		fp = 1
+	}
+
+	i1 = h & (f.buckets - 1)
+	return fp, i1, f.alt(i1, fp)
+}
+
+// alt returns the other bucket of the fingerprint fp in bucket i.
+func (f *CuckooFilter) alt(i, fp uint64) uint64 {
+	return (i ^ mix64(fp)) & (f.buckets - 1)
+}
+
+// find returns the slot of fp in bucket i, or -1.
+func (f *CuckooFilter) find(i, fp uint64) int {
+	for j := 0; j < f.bucket; j++ {
+		if f.get(i*uint64(f.bucket)+uint64(j)) == fp {
+			return j
+		}
+	}
+	return -1
+}
+
+func (f *CuckooFilter) has(item interface{}) bool {
+	fp, i1, i2 := f.locate(item)
+	return f.find(i1, fp) >= 0 || f.find(i2, fp) >= 0
+}
+
+// insert stores fp in bucket i1 or i2, moving other fingerprints to their
+// alternate buckets if both are full. If that fails too every move is undone
+// and insert returns false.
+func (f *CuckooFilter) insert(fp, i1, i2 uint64) bool {
+	for _, i := range []uint64{i1, i2} {
+		if j := f.find(i, 0); j >= 0 {
+			f.put(i*uint64(f.bucket)+uint64(j), fp)
+			return true
+		}
+	}
+
+	type move struct{ slot, fp uint64 }
+	var moves []move
+
+	i := i1
+	if f.random()&1 == 1 {
+		i = i2
+	}
+	for k := 0; k < cuckooMaxKicks; k++ {
+		slot := i*uint64(f.bucket) + f.random()%uint64(f.bucket)
+		victim := f.get(slot)
+		f.put(slot, fp)
+		moves = append(moves, move{slot, victim})
+
+		fp, i = victim, f.alt(i, victim)
+		if j := f.find(i, 0); j >= 0 {
+			f.put(i*uint64(f.bucket)+uint64(j), fp)
+			return true
+		}
+	}
+
+	for k := len(moves) - 1; k >= 0; k-- {
+		f.put(moves[k].slot, moves[k].fp)
+	}
+	return false
+}
+
+// random returns the next number of a xorshift generator, used to pick the
+// fingerprints to move.
+func (f *CuckooFilter) random() uint64 {
+	f.rnd ^= f.rnd << 13
+	f.rnd ^= f.rnd >> 7
+	f.rnd ^= f.rnd << 17
+	return f.rnd
+}
+
+// TryAdd includes the specified items (one or more) to the filter. If an
+// item finds no free slot it returns an error matching ErrFilterFull; the
+// items before it stay added, the filter is unchanged otherwise.
+func (f *CuckooFilter) TryAdd(items ...interface{}) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	for _, item := range items {
+		fp, i1, i2 := f.locate(item)
+		if f.find(i1, fp) >= 0 || f.find(i2, fp) >= 0 {
+			continue
+		}
+
+		if !f.insert(fp, i1, i2) {
+			return fmt.Errorf("set: cannot add %v with %d items: %w", item, f.n, ErrFilterFull)
+		}
+		f.n++
+	}
+	return nil
+}
+
+// Add includes the specified items (one or more) to the filter. It panics
+// with an error matching ErrFilterFull if an item finds no free slot, see
+// TryAdd.
+func (f *CuckooFilter) Add(items ...interface{}) {
+	if err := f.TryAdd(items...); err != nil {
+		panic(err)
+	}
+}
+
+// Has reports whether the items passed may be in the filter. It returns false
+// if nothing is passed. For multiple items it returns true only if all of
+// them may be in the filter.
+func (f *CuckooFilter) Has(items ...interface{}) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	f.l.RLock()
+	defer f.l.RUnlock()
+
+	for _, item := range items {
+		if !f.has(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// Remove deletes the specified items from the filter. Items which were not
+// added may remove an item with the same fingerprint, see CuckooFilter.
+func (f *CuckooFilter) Remove(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	for _, item := range items {
+		fp, i1, i2 := f.locate(item)
+		for _, i := range []uint64{i1, i2} {
+			if j := f.find(i, fp); j >= 0 {
+				f.put(i*uint64(f.bucket)+uint64(j), 0)
+				f.n--
+				break
+			}
+		}
+	}
+}
+
+// Size returns the number of items in the filter. Items colliding with one
+// added before are not counted.
+func (f *CuckooFilter) Size() int {
+	f.l.RLock()
+	defer f.l.RUnlock()
+
+	return f.n
+}
+
+// IsEmpty reports whether the filter is empty.
+func (f *CuckooFilter) IsEmpty() bool {
+	return f.Size() == 0
+}
+
+// Clear removes all items from the filter.
+func (f *CuckooFilter) Clear() {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	clear(f.slots)
+	f.n = 0
+}
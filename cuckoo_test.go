@@ -0,0 +1,170 @@
+package set
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// membership is the part of Interface a CuckooFilter shares.
+type membership interface {
+	Add(items ...interface{})
+	Has(items ...interface{}) bool
+	Remove(items ...interface{})
+	Size() int
+}
+
+func TestCuckooFilter_Interface(t *testing.T) {
+	for _, m := range []membership{New(), NewCuckooFilter(100, 0, 0)} {
+		m.Add("a", "b")
+		m.Remove("b")
+
+		if !m.Has("a") || m.Has("b") || m.Size() != 1 {
+			t.Errorf("%T: should work like a set", m)
+		}
+
+		// adding an item again changes nothing, one Remove removes it
+		for i := 0; i < 100; i++ {
+			m.Add("a")
+		}
+		m.Remove("a")
+		if m.Has("a") || m.Size() != 0 {
+			t.Errorf("%T: repeated Add should be idempotent, got size %d", m, m.Size())
+		}
+	}
+}
+
+func TestCuckooFilter_Has(t *testing.T) {
+	for _, params := range [][2]int{{8, 4}, {12, 2}, {16, 4}, {32, 1}, {5, 8}} {
+		f := NewCuckooFilter(10000, params[0], params[1])
+		for i := 0; i < 10000; i++ {
+			if err := f.TryAdd(i); err != nil {
+				t.Fatal(params, err)
+			}
+		}
+
+		// items colliding with an earlier one are not stored again
+		want := 2 * float64(params[1]) / float64(uint64(1)<<params[0])
+		if f.Size() > 10000 || float64(f.Size()) < 10000*(1-want) {
+			t.Error("Size: got", f.Size(), params)
+		}
+
+		for i := 0; i < 10000; i += 2 {
+			f.Remove(i)
+		}
+
+		// and removing one of them removes the other
+		fn := 0
+		for i := 1; i < 10000; i += 2 {
+			if !f.Has(i) {
+				fn++
+			}
+		}
+		if rate := float64(fn) / 5000; rate > want {
+			t.Errorf("Remove: false negative rate with %v should be below %v, got %v", params, want, rate)
+		}
+
+		fp := 0
+		for i := 10000; i < 60000; i++ {
+			if f.Has(i) {
+				fp++
+			}
+		}
+		if rate := float64(fp) / 50000; rate > 2*want+0.001 {
+			t.Errorf("Has: false positive rate with %v should be below %v, got %v", params, want, rate)
+		}
+	}
+}
+
+func TestCuckooFilter_Collisions(t *testing.T) {
+	// a single bucket of two slots and one bit fingerprints, every item has
+	// the same fingerprint and buckets
+	f := NewCuckooFilter(0, 1, 2)
+
+	f.Add("a")
+	f.Add("b", "a", "b")
+	if f.Size() != 1 || !f.Has("a", "b") {
+		t.Error("Add: a colliding item should not be stored again, got", f.Size())
+	}
+
+	// the filter cannot tell them apart, removing one removes both
+	f.Remove("a")
+	if f.Has("b") || !f.IsEmpty() {
+		t.Error("Remove: should remove the shared fingerprint")
+	}
+
+	// colliding items never take another slot, so they cannot fill the filter
+	f.Add("c")
+	if err := f.TryAdd("d"); err != nil || f.Size() != 1 {
+		t.Error("TryAdd: should accept a colliding item without using a slot, got", err)
+	}
+}
+
+func TestCuckooFilter_Full(t *testing.T) {
+	f := NewCuckooFilter(64, 16, 4)
+
+	var err error
+	i := 0
+	for ; err == nil; i++ {
+		err = f.TryAdd(i)
+	}
+
+	if !errors.Is(err, ErrFilterFull) {
+		t.Fatal("TryAdd: should fail with ErrFilterFull, got", err)
+	}
+	if f.Size() != i-1 || f.Size() < 120 {
+		t.Error("TryAdd: should fill the filter before failing, got", f.Size())
+	}
+
+	// a failed insert undoes its moves, no item is lost
+	for j := 0; j < i-1; j++ {
+		if !f.Has(j) {
+			t.Fatal("TryAdd: lost item", j)
+		}
+	}
+
+	func() {
+		defer func() {
+			if r, _ := recover().(error); !errors.Is(r, ErrFilterFull) {
+				t.Error("Add: should panic with ErrFilterFull, got", r)
+			}
+		}()
+		f.Add("more", "items", "than", "fit", 1.5, 2.5)
+	}()
+
+	f.Clear()
+	if !f.IsEmpty() || f.Has(0) || f.TryAdd("x") != nil {
+		t.Error("Clear: should remove all items")
+	}
+}
+
+func TestCuckooFilter_Race(t *testing.T) {
+	f := NewCuckooFilter(10000, 0, 0)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				f.Add(g*1000 + i)
+				f.Has(i)
+				if i > 0 {
+					f.Remove(g*1000 + i - 1) // added by the last iteration
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if f.Size() != 4 {
+		t.Error("Size: got", f.Size())
+	}
+}
+
+func BenchmarkCuckooFilter_Add(b *testing.B) {
+	f := NewCuckooFilter(b.N, 0, 0)
+	for i := 0; i < b.N; i++ {
+		f.Add(i)
+	}
+}
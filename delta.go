@@ -0,0 +1,141 @@
+package set
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Delta is the change from one version of a set to another: the items which
+// were added and the items which were removed. An item is never in both.
+// Deltas are created by Diff or decoded from JSON, and are much smaller than
+// the sets they relate when few items changed. A nil set is empty, so the
+// zero Delta changes nothing. See DeltaOf for typed items.
+type Delta struct {
+	Added   *SetNonTS
+	Removed *SetNonTS
+}
+
+// Diff returns the Delta from old to new: the items of new which are not in
+// old, and the items of old which are not in new.
+func Diff(old, new Interface) Delta {
+	unlock := lockSets(nil, old, new)
+	defer unlock()
+
+	o, n := view(old), view(new)
+	return Delta{
+		Added:   missingFrom(n, o),
+		Removed: missingFrom(o, n),
+	}
+}
+
+// missingFrom returns the items of s which are not in t. Neither set is
+// locked.
+func missingFrom(s, t Interface) *SetNonTS {
+	d := NewNonTS()
+	s.Each(func(item interface{}) bool {
+		if !t.Has(item) {
+			d.m[item] = keyExists
+		}
+		return true
+	})
+	return d
+}
+
+// orEmpty returns s, or a new empty set if s is nil.
+func orEmpty(s *SetNonTS) *SetNonTS {
+	if s == nil {
+		return NewNonTS()
+	}
+	return s
+}
+
+// IsEmpty reports whether d changes nothing.
+func (d Delta) IsEmpty() bool {
+	return orEmpty(d.Added).IsEmpty() && orEmpty(d.Removed).IsEmpty()
+}
+
+// Apply changes s by d: it removes the removed items from s and adds the
+// added ones. Applying Diff(old, new) to a set equal to old makes it equal to
+// new. Removing and adding are separate operations on s.
+func (d Delta) Apply(s Interface) {
+	s.Separate(orEmpty(d.Removed))
+	s.Merge(orEmpty(d.Added))
+}
+
+// Invert returns the Delta undoing d, which adds the items d removes and
+// removes the items d adds.
+func (d Delta) Invert() Delta {
+	return Delta{
+		Added:   orEmpty(d.Removed).Copy().(*SetNonTS),
+		Removed: orEmpty(d.Added).Copy().(*SetNonTS),
+	}
+}
+
+// Compose returns the Delta of d followed by e, where e is a Delta from the
+// version of the set d leads to. Items which e changes back cancel out, so
+// composing Diff(a, b) and Diff(b, c) gives Diff(a, c).
+func (d Delta) Compose(e Delta) Delta {
+	dAdded, dRemoved := orEmpty(d.Added), orEmpty(d.Removed)
+	eAdded, eRemoved := orEmpty(e.Added), orEmpty(e.Removed)
+
+	added := Difference(dAdded, eRemoved).(*SetNonTS)
+	added.Merge(Difference(eAdded, dRemoved))
+
+	removed := Difference(dRemoved, eAdded).(*SetNonTS)
+	removed.Merge(Difference(eRemoved, dAdded))
+
+	return Delta{Added: added, Removed: removed}
+}
+
+// deltaJSON is the JSON encoding of a Delta.
+type deltaJSON struct {
+	Added   json.RawMessage `json:"added"`
+	Removed json.RawMessage `json:"removed"`
+}
+
+// MarshalJSON implements json.Marshaler. A Delta is encoded as an object with
+// the sorted "added" and "removed" arrays.
+func (d Delta) MarshalJSON() ([]byte, error) {
+	added, err := SortedJSON(orEmpty(d.Added)).MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	removed, err := SortedJSON(orEmpty(d.Removed)).MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(deltaJSON{added, removed})
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Items are decoded as by
+// Set.UnmarshalJSON, so numbers become float64 and no longer match int items;
+// decode a DeltaOf to keep their type. Missing arrays are empty. On error d
+// is unchanged.
+func (d *Delta) UnmarshalJSON(data []byte) error {
+	var raw deltaJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	added, removed := NewNonTS(), NewNonTS()
+	for _, part := range []struct {
+		data []byte
+		s    *SetNonTS
+	}{{raw.Added, added}, {raw.Removed, removed}} {
+		if len(part.data) == 0 {
+			continue
+		}
+		if err := part.s.UnmarshalJSON(part.data); err != nil {
+			return err
+		}
+	}
+
+	for item := range added.m {
+		if removed.Has(item) {
+			return fmt.Errorf("set: delta both adds and removes %v", item)
+		}
+	}
+
+	d.Added, d.Removed = added, removed
+	return nil
+}
@@ -0,0 +1,133 @@
+package set
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DeltaOf is the type-parameterized counterpart of Delta. Its items keep
+// their type T when decoded from JSON, so a DeltaOf[int] sent as JSON applies
+// to the same int items it was computed from. A nil set is empty, so the zero
+// DeltaOf changes nothing.
+type DeltaOf[T comparable] struct {
+	Added   *SetNonTSOf[T]
+	Removed *SetNonTSOf[T]
+}
+
+// DiffOf returns the DeltaOf from old to new: the items of new which are not
+// in old, and the items of old which are not in new.
+func DiffOf[T comparable](old, new InterfaceOf[T]) DeltaOf[T] {
+	unlock := lockSets(nil, old, new)
+	defer unlock()
+
+	o, n := viewOf(old), viewOf(new)
+	return DeltaOf[T]{
+		Added:   missingFromOf(n, o),
+		Removed: missingFromOf(o, n),
+	}
+}
+
+// missingFromOf returns the items of s which are not in t. Neither set is
+// locked.
+func missingFromOf[T comparable](s, t InterfaceOf[T]) *SetNonTSOf[T] {
+	d := NewNonTSOf[T]()
+	s.Each(func(item T) bool {
+		if !t.Has(item) {
+			d.m[item] = keyExists
+		}
+		return true
+	})
+	return d
+}
+
+// orEmptyOf returns s, or a new empty set if s is nil.
+func orEmptyOf[T comparable](s *SetNonTSOf[T]) *SetNonTSOf[T] {
+	if s == nil {
+		return NewNonTSOf[T]()
+	}
+	return s
+}
+
+// IsEmpty reports whether d changes nothing.
+func (d DeltaOf[T]) IsEmpty() bool {
+	return orEmptyOf(d.Added).IsEmpty() && orEmptyOf(d.Removed).IsEmpty()
+}
+
+// Apply changes s by d: it removes the removed items from s and adds the
+// added ones. Applying DiffOf(old, new) to a set equal to old makes it equal
+// to new. Removing and adding are separate operations on s.
+func (d DeltaOf[T]) Apply(s InterfaceOf[T]) {
+	s.Separate(orEmptyOf(d.Removed))
+	s.Merge(orEmptyOf(d.Added))
+}
+
+// Invert returns the DeltaOf undoing d, which adds the items d removes and
+// removes the items d adds.
+func (d DeltaOf[T]) Invert() DeltaOf[T] {
+	return DeltaOf[T]{
+		Added:   orEmptyOf(d.Removed).Copy().(*SetNonTSOf[T]),
+		Removed: orEmptyOf(d.Added).Copy().(*SetNonTSOf[T]),
+	}
+}
+
+// Compose returns the DeltaOf of d followed by e, where e is a DeltaOf from
+// the version of the set d leads to. Items which e changes back cancel out,
+// so composing DiffOf(a, b) and DiffOf(b, c) gives DiffOf(a, c).
+func (d DeltaOf[T]) Compose(e DeltaOf[T]) DeltaOf[T] {
+	dAdded, dRemoved := orEmptyOf(d.Added), orEmptyOf(d.Removed)
+	eAdded, eRemoved := orEmptyOf(e.Added), orEmptyOf(e.Removed)
+
+	added := DifferenceOf[T](dAdded, eRemoved).(*SetNonTSOf[T])
+	added.Merge(DifferenceOf[T](eAdded, dRemoved))
+
+	removed := DifferenceOf[T](dRemoved, eAdded).(*SetNonTSOf[T])
+	removed.Merge(DifferenceOf[T](eRemoved, dAdded))
+
+	return DeltaOf[T]{Added: added, Removed: removed}
+}
+
+// MarshalJSON implements json.Marshaler. A DeltaOf is encoded like a Delta,
+// as an object with the sorted "added" and "removed" arrays.
+func (d DeltaOf[T]) MarshalJSON() ([]byte, error) {
+	added, err := SortedJSON(orEmptyOf(d.Added)).MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	removed, err := SortedJSON(orEmptyOf(d.Removed)).MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(deltaJSON{added, removed})
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Items are decoded into T as by
+// SetNonTSOf.UnmarshalJSON, and missing arrays are empty. On error d is
+// unchanged.
+func (d *DeltaOf[T]) UnmarshalJSON(data []byte) error {
+	var raw deltaJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	added, removed := NewNonTSOf[T](), NewNonTSOf[T]()
+	for _, part := range []struct {
+		data []byte
+		s    *SetNonTSOf[T]
+	}{{raw.Added, added}, {raw.Removed, removed}} {
+		if len(part.data) == 0 {
+			continue
+		}
+		if err := part.s.UnmarshalJSON(part.data); err != nil {
+			return err
+		}
+	}
+
+	for item := range added.m {
+		if removed.Has(item) {
+			return fmt.Errorf("set: delta both adds and removes %v", item)
+		}
+	}
+
+	d.Added, d.Removed = added, removed
+	return nil
+}
@@ -0,0 +1,56 @@
+package set
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDeltaOf_JSON(t *testing.T) {
+	old, new := NewOf(1, 2, 3), NewOf(2, 3, 4, 5)
+
+	data, err := json.Marshal(DiffOf[int](old, new))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"added":[4,5],"removed":[1]}` {
+		t.Error("MarshalJSON: should encode the sorted items, got", string(data))
+	}
+
+	var d DeltaOf[int]
+	if err := json.Unmarshal(data, &d); err != nil {
+		t.Fatal(err)
+	}
+
+	d.Apply(old)
+	if !reflect.DeepEqual(sortedInts(old), []int{2, 3, 4, 5}) {
+		t.Error("Apply: a decoded delta should change int items, got", old)
+	}
+
+	d.Invert().Apply(old)
+	if !reflect.DeepEqual(sortedInts(old), []int{1, 2, 3}) {
+		t.Error("Invert: should undo the decoded delta, got", old)
+	}
+
+	for _, bad := range []string{`{"added":[1],"removed":[1]}`, `{"added":["x"]}`, `{"added":[1.5]}`} {
+		if err := json.Unmarshal([]byte(bad), &d); err == nil {
+			t.Error("UnmarshalJSON: should reject", bad)
+		}
+	}
+}
+
+func TestDeltaOf_Compose(t *testing.T) {
+	a, b, c := NewOf("x", "y"), NewNonTSOf("y", "z"), NewOf("x", "z")
+
+	got := DiffOf[string](a, b).Compose(DiffOf[string](b, c))
+	want := DiffOf[string](a, c)
+	if !got.Added.IsEqual(want.Added) || !got.Removed.IsEqual(want.Removed) {
+		t.Error("Compose: should equal the diff of the first and last set, got", got.Added, got.Removed)
+	}
+
+	var zero DeltaOf[string]
+	zero.Apply(a)
+	if !zero.IsEmpty() || !zero.Compose(zero).IsEmpty() || a.Size() != 2 {
+		t.Error("DeltaOf: the zero delta should change nothing")
+	}
+}
@@ -0,0 +1,122 @@
+package set
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+)
+
+func randomSet(rnd *rand.Rand, n, max int) *Set {
+	s := New()
+	for i := 0; i < n; i++ {
+		s.Add(rnd.Intn(max))
+	}
+	return s
+}
+
+func TestDiff(t *testing.T) {
+	old := New("read", "write", "admin")
+	new := NewNonTS("read", "deploy")
+
+	d := Diff(old, new)
+	if !d.Added.IsEqual(New("deploy")) || !d.Removed.IsEqual(New("write", "admin")) {
+		t.Error("Diff: should return the added and removed items, got", d.Added, d.Removed)
+	}
+
+	if !Diff(old, old).IsEmpty() || d.IsEmpty() {
+		t.Error("IsEmpty: should report whether the delta changes anything")
+	}
+}
+
+func TestDelta_Apply(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		a, b := randomSet(rnd, 50, 100), randomSet(rnd, 50, 100)
+
+		s := a.Copy()
+		Diff(a, b).Apply(s)
+		if !s.IsEqual(b) {
+			t.Fatal("Apply: should turn old into new, got", s, "want", b)
+		}
+
+		Diff(a, b).Invert().Apply(s)
+		if !s.IsEqual(a) {
+			t.Fatal("Invert: should undo the delta, got", s, "want", a)
+		}
+	}
+}
+
+func TestDelta_Compose(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	for i := 0; i < 100; i++ {
+		a, b, c := randomSet(rnd, 30, 60), randomSet(rnd, 30, 60), randomSet(rnd, 30, 60)
+
+		got := Diff(a, b).Compose(Diff(b, c))
+		want := Diff(a, c)
+		if !got.Added.IsEqual(want.Added) || !got.Removed.IsEqual(want.Removed) {
+			t.Fatal("Compose: should equal the diff of the first and last set, got", got.Added, got.Removed)
+		}
+	}
+}
+
+func TestDelta_zero(t *testing.T) {
+	var d Delta
+	s := New(1, 2)
+
+	d.Apply(s)
+	if !d.IsEmpty() || !d.Invert().IsEmpty() || !s.IsEqual(New(1, 2)) {
+		t.Error("Delta: the zero delta should change nothing")
+	}
+
+	if c := d.Compose(Diff(s, New(2, 3))); !c.Added.IsEqual(New(3)) || !c.Removed.IsEqual(New(1)) {
+		t.Error("Compose: the zero delta should be neutral, got", c.Added, c.Removed)
+	}
+
+	if data, err := json.Marshal(d); err != nil || string(data) != `{"added":[],"removed":[]}` {
+		t.Error("MarshalJSON: should encode the zero delta as empty, got", string(data), err)
+	}
+}
+
+func TestDelta_Apply_events(t *testing.T) {
+	s := NewObservable(1, 2, 3)
+	r := &recorder{}
+	s.Subscribe(r.record)
+
+	Diff(s, New(2, 3, 4, 5)).Apply(s)
+	if got := formatEvents(r.events); got != "Removed[1] Added[4, 5] " {
+		t.Error("Apply: should change the set in one call per direction, got", got)
+	}
+}
+
+func TestDelta_JSON(t *testing.T) {
+	d := Diff(New("a", "b", 1.5), New("b", "c", "d"))
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"added":["c","d"],"removed":["a",1.5]}` {
+		t.Error("MarshalJSON: should encode the sorted items, got", string(data))
+	}
+
+	var decoded Delta
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Added.IsEqual(d.Added) || !decoded.Removed.IsEqual(d.Removed) {
+		t.Error("UnmarshalJSON: should decode the delta, got", decoded.Added, decoded.Removed)
+	}
+
+	if err := json.Unmarshal([]byte(`{"added":["x"]}`), &decoded); err != nil || !decoded.Removed.IsEmpty() {
+		t.Error("UnmarshalJSON: missing arrays should be empty, got", err)
+	}
+
+	for _, bad := range []string{`{"added":["x"],"removed":["x"]}`, `{"added":[[1]]}`, `[]`} {
+		if err := json.Unmarshal([]byte(bad), &decoded); err == nil {
+			t.Error("UnmarshalJSON: should reject", bad)
+		}
+	}
+	if !decoded.Added.IsEqual(New("x")) {
+		t.Error("UnmarshalJSON: should leave the delta unchanged on error")
+	}
+}
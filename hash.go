@@ -0,0 +1,121 @@
+package set
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// ErrIncompatible is returned when combining filters or sketches which were
+// built with different parameters.
+var ErrIncompatible = errors.New("set: incompatible parameters")
+
+// hashItem returns a 64-bit hash of item which, unlike maphash, is the same
+// in every process, so that filters and sketches can be marshalled. Items of
+// the scalar built-in kinds are hashed by their binary encoding, including
+// their type, so 1 and int64(1) differ as they do in a set, while 0.0 and
+// -0.0 are the same. Other items are hashed by their type and their value,
+// see appendValue, so items which are equal in a set have the same hash.
+func hashItem(item interface{}) uint64 {
+	var arr [64]byte
+	buf, ok := appendScalar(arr[:0], positiveZero(item))
+	if !ok {
+		buf = fmt.Appendf(buf, "%T", item)
+		buf = appendValue(buf, reflect.ValueOf(item))
+	}
+
+	// FNV-1a, whose weak low bits are mixed by the murmur3 finalizer
+	h := uint64(14695981039346656037)
+	for _, b := range buf {
+		h ^= uint64(b)
+		h *= 1099511628211
+	}
+	return mix64(h)
+}
+
+// positiveZero returns item with a negative zero float, or complex part,
+// replaced by a positive zero, which is the same key in a map.
+func positiveZero(item interface{}) interface{} {
+	switch v := item.(type) {
+	case float32:
+		if v == 0 {
+			return float32(0)
+		}
+	case float64:
+		if v == 0 {
+			return float64(0)
+		}
+	case complex64:
+		return complex(positiveZero(real(v)).(float32), positiveZero(imag(v)).(float32))
+	case complex128:
+		return complex(positiveZero(real(v)).(float64), positiveZero(imag(v)).(float64))
+	}
+	return item
+}
+
+// appendValue appends the value of v, field by field and element by element,
+// in the way == compares it: negative zeros as zeros, interfaces by their
+// dynamic type and value, and pointers, channels and funcs by their address,
+// so their hashes are only the same within one process. Maps are appended
+// by their %#v formatting.
+func appendValue(buf []byte, v reflect.Value) []byte {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return append(buf, 1)
+		}
+		return append(buf, 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return binary.AppendVarint(buf, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return binary.AppendUvarint(buf, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return appendFloat(buf, v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		return appendFloat(appendFloat(buf, real(v.Complex())), imag(v.Complex()))
+	case reflect.String:
+		return append(binary.AppendUvarint(buf, uint64(v.Len())), v.String()...)
+	case reflect.Pointer, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return binary.LittleEndian.AppendUint64(buf, uint64(v.Pointer()))
+	case reflect.Interface:
+		if v.IsNil() {
+			return append(buf, 0)
+		}
+		t := v.Elem().Type().String()
+		buf = append(binary.AppendUvarint(append(buf, 1), uint64(len(t))), t...)
+		return appendValue(buf, v.Elem())
+	case reflect.Array, reflect.Slice:
+		buf = binary.AppendUvarint(buf, uint64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			buf = appendValue(buf, v.Index(i))
+		}
+		return buf
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			buf = appendValue(buf, v.Field(i))
+		}
+		return buf
+	}
+	return fmt.Appendf(buf, "%#v", v)
+}
+
+// appendFloat appends f with a negative zero as a positive zero.
+func appendFloat(buf []byte, f float64) []byte {
+	if f == 0 {
+		f = 0
+	}
+	return binary.LittleEndian.AppendUint64(buf, math.Float64bits(f))
+}
+
+// mix64 is the finalizer of murmur3, every input bit affects every output
+// bit.
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
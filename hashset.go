@@ -0,0 +1,428 @@
+package set
+
+import (
+	"bytes"
+	"hash/maphash"
+	"iter"
+)
+
+// Hasher hashes and compares the items of a HashSet. Items which are Equal
+// must have the same Hash.
+type Hasher interface {
+	Hash(item interface{}) uint64
+	Equal(a, b interface{}) bool
+}
+
+// HasherFuncs is a Hasher built from two functions.
+type HasherFuncs struct {
+	HashFunc  func(item interface{}) uint64
+	EqualFunc func(a, b interface{}) bool
+}
+
+// Hash calls h.HashFunc.
+func (h HasherFuncs) Hash(item interface{}) uint64 {
+	return h.HashFunc(item)
+}
+
+// Equal calls h.EqualFunc.
+func (h HasherFuncs) Equal(a, b interface{}) bool {
+	return h.EqualFunc(a, b)
+}
+
+// bytesSeed seeds the hashes of BytesHasher.
+var bytesSeed = maphash.MakeSeed()
+
+// BytesHasher is a Hasher for []byte items, which compares them by content.
+var BytesHasher Hasher = HasherFuncs{
+	HashFunc: func(item interface{}) uint64 {
+		return maphash.Bytes(bytesSeed, item.([]byte))
+	},
+	EqualFunc: func(a, b interface{}) bool {
+		return bytes.Equal(a.([]byte), b.([]byte))
+	},
+}
+
+// Provides a common baseline for both threadsafe and non-ts hash sets. Items
+// are kept in buckets by their hash, items with colliding hashes share a
+// bucket and are told apart by the Hasher's Equal.
+type hashSet struct {
+	buckets map[uint64][]interface{}
+	size    int
+	hasher  Hasher
+}
+
+// HashSetNonTS defines a non-thread safe set data structure for items which
+// are not comparable, such as []byte, slices or structs containing maps.
+// Membership is decided by a user supplied Hasher instead of ==.
+//
+// Operations mixing a HashSet with the map based sets of this package only
+// work for hashable items, as the map based sets cannot look up others.
+type HashSetNonTS struct {
+	hashSet
+}
+
+// HashSet defines a thread safe set data structure for items which are not
+// comparable, such as []byte, slices or structs containing maps. Membership
+// is decided by a user supplied Hasher instead of ==.
+//
+// Operations mixing a HashSet with the map based sets of this package only
+// work for hashable items, as the map based sets cannot look up others.
+type HashSet struct {
+	hashSet
+	setLock
+}
+
+// NewHashNonTS creates and initialize a new non-threadsafe HashSetNonTS using
+// h. It accepts a variable number of arguments to populate the initial set.
+func NewHashNonTS(h Hasher, items ...interface{}) *HashSetNonTS {
+	s := &HashSetNonTS{}
+	s.hasher = h
+	s.buckets = make(map[uint64][]interface{})
+
+	// Ensure interface compliance
+	var _ Interface = s
+
+	s.Add(items...)
+	return s
+}
+
+// NewHash creates and initialize a new HashSet using h. It accepts a variable
+// number of arguments to populate the initial set.
+func NewHash(h Hasher, items ...interface{}) *HashSet {
+	s := &HashSet{}
+	s.hasher = h
+	s.buckets = make(map[uint64][]interface{})
+
+	// Ensure interface compliance
+	var _ Interface = s
+
+	s.Add(items...)
+	return s
+}
+
+// Hasher returns the Hasher of s.
+func (s *hashSet) Hasher() Hasher {
+	return s.hasher
+}
+
+// find returns the hash of item and its position in its bucket, or -1.
+func (s *hashSet) find(item interface{}) (uint64, int) {
+	h := s.hasher.Hash(item)
+	for i, other := range s.buckets[h] {
+		if s.hasher.Equal(item, other) {
+			return h, i
+		}
+	}
+	return h, -1
+}
+
+// New creates and initalizes a new HashSetNonTS with the same Hasher.
+func (s *hashSet) New(items ...interface{}) Interface {
+	return NewHashNonTS(s.hasher, items...)
+}
+
+// Add includes the specified items (one or more) to the set.
+func (s *hashSet) Add(items ...interface{}) {
+	for _, item := range items {
+		if h, i := s.find(item); i < 0 {
+			s.buckets[h] = append(s.buckets[h], item)
+			s.size++
+		}
+	}
+}
+
+// Remove deletes the specified items from the set.
+func (s *hashSet) Remove(items ...interface{}) {
+	for _, item := range items {
+		h, i := s.find(item)
+		if i < 0 {
+			continue
+		}
+
+		bucket := s.buckets[h]
+		if len(bucket) == 1 {
+			delete(s.buckets, h)
+		} else {
+			bucket[i] = bucket[len(bucket)-1]
+			bucket[len(bucket)-1] = nil
+			s.buckets[h] = bucket[:len(bucket)-1]
+		}
+		s.size--
+	}
+}
+
+// Pop deletes and return an item from the set. If set is empty, nil is
+// returned.
+func (s *hashSet) Pop() interface{} {
+	for _, bucket := range s.buckets {
+		item := bucket[len(bucket)-1]
+		s.Remove(item)
+		return item
+	}
+	return nil
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (s *hashSet) Has(items ...interface{}) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	for _, item := range items {
+		if _, i := s.find(item); i < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Size returns the number of items in a set.
+func (s *hashSet) Size() int {
+	return s.size
+}
+
+// Clear removes all items from the set.
+func (s *hashSet) Clear() {
+	s.buckets = make(map[uint64][]interface{})
+	s.size = 0
+}
+
+// IsEmpty reports whether the set is empty.
+func (s *hashSet) IsEmpty() bool {
+	return s.size == 0
+}
+
+// IsEqual test whether s and t are the same in size and have the same items.
+func (s *hashSet) IsEqual(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isEqual(s, view(t))
+}
+
+// IsSubset tests whether t is a subset of s.
+func (s *hashSet) IsSubset(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isSubset(s, view(t))
+}
+
+// IsSuperset tests whether t is a superset of s.
+func (s *hashSet) IsSuperset(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isSubset(view(t), s)
+}
+
+// Each traverses the items in the set, calling the provided function for each
+// set member. Traversal will continue until all items in the set have been
+// visited, or if the closure returns false.
+func (s *hashSet) Each(f func(item interface{}) bool) {
+	for _, bucket := range s.buckets {
+		for _, item := range bucket {
+			if !f(item) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the items of the set, visiting them in the
+// same order as Each.
+func (s *hashSet) All() iter.Seq[interface{}] {
+	return func(yield func(item interface{}) bool) {
+		s.Each(yield)
+	}
+}
+
+// String returns a string representation of s
+func (s *hashSet) String() string {
+	return formatItems(s.List())
+}
+
+// List returns a slice of all items.
+func (s *hashSet) List() []interface{} {
+	list := make([]interface{}, 0, s.size)
+	for _, bucket := range s.buckets {
+		list = append(list, bucket...)
+	}
+	return list
+}
+
+// Copy returns a new HashSetNonTS with a copy of s.
+func (s *hashSet) Copy() Interface {
+	return NewHashNonTS(s.hasher, s.List()...)
+}
+
+// Merge is like Union, however it modifies the current set it's applied on
+// with the given t set.
+func (s *hashSet) Merge(t Interface) {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	s.Add(view(t).List()...)
+}
+
+// Separate removes the set items containing in t from set s. Please aware that
+// it's not the opposite of Merge.
+func (s *hashSet) Separate(t Interface) {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	s.Remove(view(t).List()...)
+}
+
+// New creates and initalizes a new HashSet with the same Hasher.
+func (s *HashSet) New(items ...interface{}) Interface {
+	return NewHash(s.hasher, items...)
+}
+
+// Add includes the specified items (one or more) to the set.
+func (s *HashSet) Add(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.hashSet.Add(items...)
+}
+
+// Remove deletes the specified items from the set.
+func (s *HashSet) Remove(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.hashSet.Remove(items...)
+}
+
+// Pop deletes and return an item from the set. If set is empty, nil is
+// returned.
+func (s *HashSet) Pop() interface{} {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return s.hashSet.Pop()
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (s *HashSet) Has(items ...interface{}) bool {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.hashSet.Has(items...)
+}
+
+// Size returns the number of items in a set.
+func (s *HashSet) Size() int {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.size
+}
+
+// Clear removes all items from the set.
+func (s *HashSet) Clear() {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.hashSet.Clear()
+}
+
+// IsEmpty reports whether the set is empty.
+func (s *HashSet) IsEmpty() bool {
+	return s.Size() == 0
+}
+
+// IsEqual test whether s and t are the same in size and have the same items.
+func (s *HashSet) IsEqual(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isEqual(&s.hashSet, view(t))
+}
+
+// IsSubset tests whether t is a subset of s.
+func (s *HashSet) IsSubset(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isSubset(&s.hashSet, view(t))
+}
+
+// IsSuperset tests whether t is a superset of s.
+func (s *HashSet) IsSuperset(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isSubset(view(t), &s.hashSet)
+}
+
+// Each traverses the items in the set, calling the provided function for each
+// set member. Traversal will continue until all items in the set have been
+// visited, or if the closure returns false.
+func (s *HashSet) Each(f func(item interface{}) bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	s.hashSet.Each(f)
+}
+
+// All returns an iterator over the items of the set, visiting them in the
+// same order as Each. As with Each, s is read locked from the start of the
+// loop until it ends, so the loop body must not modify s.
+func (s *HashSet) All() iter.Seq[interface{}] {
+	return func(yield func(item interface{}) bool) {
+		s.Each(yield)
+	}
+}
+
+// String returns a string representation of s
+func (s *HashSet) String() string {
+	return formatItems(s.List())
+}
+
+// List returns a slice of all items.
+func (s *HashSet) List() []interface{} {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.hashSet.List()
+}
+
+// Copy returns a new HashSet with a copy of s.
+func (s *HashSet) Copy() Interface {
+	return NewHash(s.hasher, s.List()...)
+}
+
+// Merge is like Union, however it modifies the current set it's applied on
+// with the given t set.
+func (s *HashSet) Merge(t Interface) {
+	unlock := lockSets(s, t)
+	defer unlock()
+
+	s.hashSet.Add(view(t).List()...)
+}
+
+// Separate removes the set items containing in t from set s. Please aware that
+// it's not the opposite of Merge.
+func (s *HashSet) Separate(t Interface) {
+	unlock := lockSets(s, t)
+	defer unlock()
+
+	s.hashSet.Remove(view(t).List()...)
+}
+
+// unlocked returns the underlying hash set of s, which does not lock.
+func (s *HashSet) unlocked() Interface {
+	return &s.hashSet
+}
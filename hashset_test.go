@@ -0,0 +1,123 @@
+package set
+
+import (
+	"bytes"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// idsHasher hashes []int items by their length only, so that many distinct
+// items collide.
+var idsHasher = HasherFuncs{
+	HashFunc: func(item interface{}) uint64 {
+		return uint64(len(item.([]int)))
+	},
+	EqualFunc: func(a, b interface{}) bool {
+		return reflect.DeepEqual(a, b)
+	},
+}
+
+func TestHashSet_Bytes(t *testing.T) {
+	for _, s := range []Interface{NewHash(BytesHasher), NewHashNonTS(BytesHasher)} {
+		s.Add([]byte("a"), []byte("b"), []byte("a"))
+
+		if s.Size() != 2 || !s.Has([]byte("a"), []byte("b")) || s.Has([]byte("c")) {
+			t.Error("Add: []byte items should be compared by content")
+		}
+
+		s.Remove([]byte("a"))
+		if s.Has([]byte("a")) || s.Size() != 1 {
+			t.Error("Remove: item should be removed")
+		}
+
+		item := s.Pop()
+		if !bytes.Equal(item.([]byte), []byte("b")) || !s.IsEmpty() {
+			t.Error("Pop: should return the last item")
+		}
+
+		if s.Pop() != nil {
+			t.Error("Pop: should return nil because set is empty")
+		}
+	}
+}
+
+func TestHashSet_Collisions(t *testing.T) {
+	s := NewHashNonTS(idsHasher, []int{1, 2}, []int{3, 4}, []int{5, 6}, []int{1})
+
+	if s.Size() != 4 || !s.Has([]int{3, 4}, []int{1}) || s.Has([]int{4, 3}) {
+		t.Error("Has: colliding items should be told apart by Equal")
+	}
+
+	s.Remove([]int{3, 4})
+	if s.Size() != 3 || s.Has([]int{3, 4}) || !s.Has([]int{1, 2}, []int{5, 6}) {
+		t.Error("Remove: only the equal item should be removed from the bucket")
+	}
+
+	if len(s.List()) != 3 {
+		t.Error("List: should return every item")
+	}
+
+	s.Clear()
+	if !s.IsEmpty() {
+		t.Error("Clear: set should be empty")
+	}
+}
+
+func TestHashSet_Operations(t *testing.T) {
+	a := NewHash(idsHasher, []int{1}, []int{2}, []int{3})
+	b := NewHashNonTS(idsHasher, []int{2}, []int{3}, []int{4})
+
+	u := Union(a, b)
+	if hs, ok := u.(*HashSet); !ok || hs.Hasher() == nil || u.Size() != 4 {
+		t.Error("Union: should keep the set type and hasher of the first set")
+	}
+
+	i := Intersection(b, a)
+	if hs, ok := i.(*HashSetNonTS); !ok || hs.Hasher() == nil || !i.IsEqual(NewHash(idsHasher, []int{2}, []int{3})) {
+		t.Error("Intersection: should keep the set type and hasher of the first set, got", i)
+	}
+
+	if d := Difference(a, b); !d.Has([]int{1}) || d.Size() != 1 {
+		t.Error("Difference: wrong items", d)
+	}
+
+	if !a.IsSubset(NewHashNonTS(idsHasher, []int{1})) || !a.Copy().IsEqual(a) {
+		t.Error("IsSubset: wrong result")
+	}
+
+	a.Merge(b)
+	a.Separate(NewHash(idsHasher, []int{1}))
+	if !a.IsEqual(b) {
+		t.Error("Merge: wrong items after Merge and Separate", a)
+	}
+
+	// comparable items still work together with the map based sets
+	h := NewHash(HasherFuncs{
+		HashFunc:  func(item interface{}) uint64 { return uint64(len(item.(string))) },
+		EqualFunc: func(a, b interface{}) bool { return a == b },
+	}, "x", "yy")
+	if !h.IsEqual(New("x", "yy")) || !h.IsSuperset(New("x", "yy", "z")) {
+		t.Error("IsEqual: should work against a map based set for comparable items")
+	}
+}
+
+func TestHashSet_Race(t *testing.T) {
+	s := NewHash(BytesHasher)
+	u := NewHash(BytesHasher)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				s.Add([]byte{byte(g), byte(i)})
+				u.Merge(s)
+				s.IsEqual(u)
+				s.Pop()
+			}
+		}(g)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,181 @@
+package set
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// hllVersion is the version of the binary encoding written by
+// HyperLogLog.MarshalBinary: the version byte, the precision byte and then
+// one byte per register.
+const hllVersion = 1
+
+// HyperLogLog is a sketch estimating the number of distinct items added to
+// it, in 2^precision bytes no matter how many items there are. The standard
+// error of Count is 1.04/sqrt(2^precision), 0.81% for precision 14.
+//
+// Sketches of the same precision merge without loss: the merged sketch is
+// the one of the union of the added items, so the distinct items across
+// shards are counted by merging the sketches of the shards. Items are hashed
+// the same way in every process, so marshalled sketches can be shared.
+//
+// It is safe for concurrent use.
+type HyperLogLog struct {
+	registers []uint8
+	p         uint8
+	setLock
+}
+
+// NewHyperLogLog creates an empty HyperLogLog with 2^precision registers.
+// The precision must be between 4 and 18.
+func NewHyperLogLog(precision int) *HyperLogLog {
+	if precision < 4 || precision > 18 {
+		panic(fmt.Errorf("set: HyperLogLog precision %d is not between 4 and 18", precision))
+	}
+
+	return &HyperLogLog{registers: make([]uint8, 1<<precision), p: uint8(precision)}
+}
+
+// NewHyperLogLogFrom creates a HyperLogLog with 2^precision registers and
+// the items of s.
+func NewHyperLogLogFrom(s Interface, precision int) *HyperLogLog {
+	h := NewHyperLogLog(precision)
+
+	unlock := lockSets(nil, s)
+	defer unlock()
+
+	view(s).Each(func(item interface{}) bool {
+		h.add(hashItem(item))
+		return true
+	})
+	return h
+}
+
+// add records the item hash x: the first p bits of x select a register which
+// keeps the highest position of the first one bit in the remaining bits.
+func (h *HyperLogLog) add(x uint64) {
+	i := x >> (64 - h.p)
+	rho := uint8(bits.LeadingZeros64(x<<h.p|1<<(h.p-1))) + 1
+
+	if rho > h.registers[i] {
+		h.registers[i] = rho
+	}
+}
+
+// Add includes the specified items (one or more) to the sketch.
+func (h *HyperLogLog) Add(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	h.l.Lock()
+	defer h.l.Unlock()
+
+	for _, item := range items {
+		h.add(hashItem(item))
+	}
+}
+
+// Count returns the estimated number of distinct items added to the sketch.
+func (h *HyperLogLog) Count() uint64 {
+	h.l.RLock()
+	defer h.l.RUnlock()
+
+	m := float64(len(h.registers))
+
+	sum, zeros := 0.0, 0
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	var alpha float64
+	switch len(h.registers) {
+	case 16:
+		alpha = 0.673
+	case 32:
+		alpha = 0.697
+	case 64:
+		alpha = 0.709
+	default:
+		alpha = 0.7213 / (1 + 1.079/m)
+	}
+
+	e := alpha * m * m / sum
+	if e <= 2.5*m && zeros > 0 {
+		// linear counting is more precise for few items
+		e = m * math.Log(m/float64(zeros))
+	}
+	return uint64(math.Round(e))
+}
+
+// Precision returns the precision the sketch was created with.
+func (h *HyperLogLog) Precision() int {
+	return int(h.p)
+}
+
+// Clear removes all items from the sketch.
+func (h *HyperLogLog) Clear() {
+	h.l.Lock()
+	defer h.l.Unlock()
+
+	clear(h.registers)
+}
+
+// Merge adds the items of t to h, afterwards h is the sketch of the union of
+// both. It returns ErrIncompatible unless both have the same precision.
+func (h *HyperLogLog) Merge(t *HyperLogLog) error {
+	unlock := lockSets(h, t)
+	defer unlock()
+
+	if h.p != t.p {
+		return ErrIncompatible
+	}
+
+	for i, r := range t.registers {
+		h.registers[i] = max(h.registers[i], r)
+	}
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (h *HyperLogLog) MarshalBinary() ([]byte, error) {
+	h.l.RLock()
+	defer h.l.RUnlock()
+
+	buf := make([]byte, 0, 2+len(h.registers))
+	buf = append(buf, hllVersion, h.p)
+	return append(buf, h.registers...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It replaces h with
+// the decoded sketch, including its precision. On error h is unchanged.
+func (h *HyperLogLog) UnmarshalBinary(data []byte) error {
+	switch {
+	case len(data) < 2:
+		return errBinaryTruncated
+	case data[0] != hllVersion:
+		return fmt.Errorf("set: unsupported HyperLogLog encoding version %d", data[0])
+	case data[1] < 4 || data[1] > 18:
+		return fmt.Errorf("set: invalid HyperLogLog precision %d", data[1])
+	case len(data)-2 != 1<<data[1]:
+		return fmt.Errorf("set: HyperLogLog of precision %d has %d registers", data[1], len(data)-2)
+	}
+
+	p := data[1]
+	for _, r := range data[2:] {
+		if r > 64-p+1 {
+			return fmt.Errorf("set: invalid HyperLogLog register %d", r)
+		}
+	}
+
+	h.l.Lock()
+	defer h.l.Unlock()
+
+	h.p = p
+	h.registers = append([]uint8(nil), data[2:]...)
+	return nil
+}
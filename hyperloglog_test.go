@@ -0,0 +1,114 @@
+package set
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"testing"
+)
+
+// TestHyperLogLog_Union compares merged sketches of overlapping shards with
+// the exact size of the Union of the shards.
+func TestHyperLogLog_Union(t *testing.T) {
+	for _, p := range []int{10, 14} {
+		bound := 3 * 1.04 / math.Sqrt(float64(int(1)<<p))
+
+		for _, size := range []int{100, 5000, 200000} {
+			shards := make([]Interface, 4)
+			sketches := make([]*HyperLogLog, 4)
+			for s := range shards {
+				shards[s] = NewNonTS()
+				// every shard overlaps half of the next one
+				for i := 0; i < size; i++ {
+					shards[s].Add(s*size/2 + i)
+				}
+				sketches[s] = NewHyperLogLogFrom(shards[s], p)
+			}
+
+			merged := NewHyperLogLog(p)
+			for _, h := range sketches {
+				if err := merged.Merge(h); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			exact := Union(shards[0], shards[1], shards[2:]...).Size()
+			got := merged.Count()
+			if e := math.Abs(float64(got)-float64(exact)) / float64(exact); e > bound {
+				t.Errorf("Count: precision %d estimated %d distinct items, want %d, error %.4f > %.4f", p, got, exact, e, bound)
+			}
+		}
+	}
+}
+
+func TestHyperLogLog_Add(t *testing.T) {
+	h := NewHyperLogLog(14)
+	if h.Count() != 0 {
+		t.Error("Count: should be 0 for an empty sketch, got", h.Count())
+	}
+
+	for i := 0; i < 3; i++ {
+		h.Add("a", "b", "c", 1, int64(1))
+	}
+	if h.Count() != 5 {
+		t.Error("Count: should count distinct items exactly for few items, got", h.Count())
+	}
+
+	h.Clear()
+	if h.Count() != 0 || h.Precision() != 14 {
+		t.Error("Clear: should remove all items")
+	}
+
+	if err := h.Merge(NewHyperLogLog(12)); !errors.Is(err, ErrIncompatible) {
+		t.Error("Merge: should fail for another precision, got", err)
+	}
+}
+
+func TestHyperLogLog_MarshalBinary(t *testing.T) {
+	h := NewHyperLogLog(8)
+	for i := 0; i < 1000; i++ {
+		h.Add(i)
+	}
+
+	data, err := h.MarshalBinary()
+	if err != nil || len(data) != 2+256 {
+		t.Fatal("MarshalBinary: got", len(data), err)
+	}
+
+	u := NewHyperLogLog(4)
+	if err := u.UnmarshalBinary(data); err != nil || u.Count() != h.Count() || u.Precision() != 8 {
+		t.Error("UnmarshalBinary: should restore the sketch", err)
+	}
+
+	for _, bad := range [][]byte{nil, data[:100], {2, 8}, {1, 3}, append([]byte{1, 4}, make([]byte, 15)...)} {
+		if err := u.UnmarshalBinary(bad); err == nil {
+			t.Error("UnmarshalBinary: should fail for", bad)
+		}
+	}
+	if u.Precision() != 8 {
+		t.Error("UnmarshalBinary: should leave the sketch unchanged on error")
+	}
+}
+
+func TestHyperLogLog_Race(t *testing.T) {
+	a, b := NewHyperLogLog(10), NewHyperLogLog(10)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				a.Add(g*200 + i)
+				b.Merge(a)
+				a.Merge(b)
+				b.Count()
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if n := b.Count(); n < 750 || n > 850 {
+		t.Error("Count: should be about 800, got", n)
+	}
+}
@@ -0,0 +1,180 @@
+package set
+
+import "iter"
+
+// Collect creates a new Set with the items produced by seq.
+func Collect(seq iter.Seq[interface{}]) *Set {
+	s := New()
+	for item := range seq {
+		s.m[item] = keyExists
+	}
+	return s
+}
+
+// CollectNonTS creates a new SetNonTS with the items produced by seq.
+func CollectNonTS(seq iter.Seq[interface{}]) *SetNonTS {
+	s := NewNonTS()
+	for item := range seq {
+		s.m[item] = keyExists
+	}
+	return s
+}
+
+// CollectOf creates a new SetOf with the items produced by seq.
+func CollectOf[T comparable](seq iter.Seq[T]) *SetOf[T] {
+	s := NewOf[T]()
+	for item := range seq {
+		s.m[item] = keyExists
+	}
+	return s
+}
+
+// CollectNonTSOf creates a new SetNonTSOf with the items produced by seq.
+func CollectNonTSOf[T comparable](seq iter.Seq[T]) *SetNonTSOf[T] {
+	s := NewNonTSOf[T]()
+	for item := range seq {
+		s.m[item] = keyExists
+	}
+	return s
+}
+
+// UnionSeq returns an iterator over the items of all given sets, visiting
+// every item once. Unlike Union it does not allocate a result set. The
+// threadsafe sets among the arguments are read locked from the start of the
+// loop until it ends, so the loop body must not modify them.
+func UnionSeq(set1, set2 Interface, sets ...Interface) iter.Seq[interface{}] {
+	all := append([]Interface{set1, set2}, sets...)
+
+	return func(yield func(item interface{}) bool) {
+		unlock := lockInterfaces(all)
+		defer unlock()
+
+		for i, set := range all {
+			more := true
+			view(set).Each(func(item interface{}) bool {
+				for _, seen := range all[:i] {
+					if view(seen).Has(item) {
+						return true
+					}
+				}
+				more = yield(item)
+				return more
+			})
+
+			if !more {
+				return
+			}
+		}
+	}
+}
+
+// IntersectionSeq returns an iterator over the items that exist in all given
+// sets. Unlike Intersection it does not allocate a result set. Locking works
+// as for UnionSeq.
+func IntersectionSeq(set1, set2 Interface, sets ...Interface) iter.Seq[interface{}] {
+	all := append([]Interface{set1, set2}, sets...)
+	others := all[1:]
+
+	return func(yield func(item interface{}) bool) {
+		unlock := lockInterfaces(all)
+		defer unlock()
+
+		view(set1).Each(func(item interface{}) bool {
+			for _, set := range others {
+				if !view(set).Has(item) {
+					return true
+				}
+			}
+			return yield(item)
+		})
+	}
+}
+
+// DifferenceSeq returns an iterator over the items of the first set which are
+// not in the others. Unlike Difference it does not allocate a result set.
+// Locking works as for UnionSeq.
+func DifferenceSeq(set1, set2 Interface, sets ...Interface) iter.Seq[interface{}] {
+	all := append([]Interface{set1, set2}, sets...)
+	others := all[1:]
+
+	return func(yield func(item interface{}) bool) {
+		unlock := lockInterfaces(all)
+		defer unlock()
+
+		view(set1).Each(func(item interface{}) bool {
+			for _, set := range others {
+				if view(set).Has(item) {
+					return true
+				}
+			}
+			return yield(item)
+		})
+	}
+}
+
+// UnionSeqOf is the type-parameterized counterpart of UnionSeq.
+func UnionSeqOf[T comparable](set1, set2 InterfaceOf[T], sets ...InterfaceOf[T]) iter.Seq[T] {
+	all := append([]InterfaceOf[T]{set1, set2}, sets...)
+
+	return func(yield func(item T) bool) {
+		unlock := lockInterfacesOf(all)
+		defer unlock()
+
+		for i, set := range all {
+			more := true
+			viewOf(set).Each(func(item T) bool {
+				for _, seen := range all[:i] {
+					if viewOf(seen).Has(item) {
+						return true
+					}
+				}
+				more = yield(item)
+				return more
+			})
+
+			if !more {
+				return
+			}
+		}
+	}
+}
+
+// IntersectionSeqOf is the type-parameterized counterpart of IntersectionSeq.
+func IntersectionSeqOf[T comparable](set1, set2 InterfaceOf[T], sets ...InterfaceOf[T]) iter.Seq[T] {
+	all := append([]InterfaceOf[T]{set1, set2}, sets...)
+	others := all[1:]
+
+	return func(yield func(item T) bool) {
+		unlock := lockInterfacesOf(all)
+		defer unlock()
+
+		viewOf(set1).Each(func(item T) bool {
+			for _, set := range others {
+				if !viewOf(set).Has(item) {
+					return true
+				}
+			}
+			return yield(item)
+		})
+	}
+}
+
+// DifferenceSeqOf is the type-parameterized counterpart of DifferenceSeq.
+func DifferenceSeqOf[T comparable](set1, set2 InterfaceOf[T], sets ...InterfaceOf[T]) iter.Seq[T] {
+	all := append([]InterfaceOf[T]{set1, set2}, sets...)
+	others := all[1:]
+
+	return func(yield func(item T) bool) {
+		unlock := lockInterfacesOf(all)
+		defer unlock()
+
+		viewOf(set1).Each(func(item T) bool {
+			for _, set := range others {
+				if viewOf(set).Has(item) {
+					return true
+				}
+			}
+			return yield(item)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package set
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func TestAll(t *testing.T) {
+	sets := []Interface{
+		New(1, 2, 3),
+		NewNonTS(1, 2, 3),
+		NewSharded(4, 1, 2, 3),
+		NewOrdered(1, 2, 3),
+		NewOrderedNonTS(1, 2, 3),
+		NewSorted(CompareOrdered[int], 1, 2, 3),
+		NewSortedNonTS(CompareOrdered[int], 1, 2, 3),
+	}
+
+	for _, s := range sets {
+		var items []int
+		for item := range s.All() {
+			items = append(items, item.(int))
+		}
+		sort.Ints(items)
+
+		if !slices.Equal(items, []int{1, 2, 3}) {
+			t.Errorf("All: %T should visit every item, got %v", s, items)
+		}
+
+		n := 0
+		for range s.All() {
+			n++
+			break
+		}
+		if n != 1 {
+			t.Errorf("All: %T should stop when the loop breaks", s)
+		}
+	}
+
+	if got := slices.Collect(NewOrdered("c", "a", "b").All()); !slices.Equal(got, []interface{}{"c", "a", "b"}) {
+		t.Error("All: OrderedSet should visit items in insertion order, got", got)
+	}
+
+	if got := slices.Sorted(NewOf(3, 1, 2).All()); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Error("All: SetOf should visit every item, got", got)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	seq := slices.Values([]interface{}{"a", "b", "a"})
+
+	if s := Collect(seq); !s.IsEqual(New("a", "b")) {
+		t.Error("Collect: should contain every item of seq, got", s)
+	}
+
+	if s := CollectNonTS(seq); !s.IsEqual(New("a", "b")) {
+		t.Error("CollectNonTS: should contain every item of seq, got", s)
+	}
+
+	m := map[string]int{"x": 1, "y": 2}
+	if s := CollectOf(maps.Keys(m)); !s.IsEqual(NewOf("x", "y")) {
+		t.Error("CollectOf: should contain every item of seq, got", s)
+	}
+
+	if s := CollectNonTSOf(maps.Values(m)); !s.IsEqual(NewOf(1, 2)) {
+		t.Error("CollectNonTSOf: should contain every item of seq, got", s)
+	}
+}
+
+func TestSeq(t *testing.T) {
+	s1 := New(1, 3, 4, 5)
+	s2 := NewNonTS(2, 3, 5, 6)
+	s3 := NewSharded(2, 4, 5, 6, 7)
+
+	union := IntSlice(CollectNonTS(UnionSeq(s1, s2, s3)))
+	sort.Ints(union)
+	if !slices.Equal(union, []int{1, 2, 3, 4, 5, 6, 7}) {
+		t.Error("UnionSeq: wrong items", union)
+	}
+
+	n := 0
+	for range UnionSeq(s1, s2, s3) {
+		n++
+	}
+	if n != 7 {
+		t.Error("UnionSeq: every item should be visited once, got", n)
+	}
+
+	if s := CollectNonTS(IntersectionSeq(s1, s2, s3)); !s.IsEqual(New(5)) {
+		t.Error("IntersectionSeq: wrong items", s)
+	}
+
+	if s := CollectNonTS(DifferenceSeq(s1, s2, s3)); !s.IsEqual(New(1)) {
+		t.Error("DifferenceSeq: wrong items", s)
+	}
+
+	// breaking out of the loop releases the locks
+	for range UnionSeq(s1, s1) {
+		break
+	}
+	s1.Add(8)
+
+	a := NewOf("a", "b", "c")
+	b := NewNonTSOf("b", "c", "d")
+
+	if got := slices.Sorted(UnionSeqOf[string](a, b)); !slices.Equal(got, []string{"a", "b", "c", "d"}) {
+		t.Error("UnionSeqOf: wrong items", got)
+	}
+
+	if got := slices.Sorted(IntersectionSeqOf[string](a, b)); !slices.Equal(got, []string{"b", "c"}) {
+		t.Error("IntersectionSeqOf: wrong items", got)
+	}
+
+	if got := slices.Sorted(DifferenceSeqOf[string](a, b)); !slices.Equal(got, []string{"a"}) {
+		t.Error("DifferenceSeqOf: wrong items", got)
+	}
+}
+
+func BenchmarkIntersectionSeq(b *testing.B) {
+	s1 := New()
+	s2 := New()
+	for i := 0; i < 1000; i++ {
+		s1.Add(i)
+		s2.Add(i * 2)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for range IntersectionSeq(s1, s2) {
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package set
+
+import "math/bits"
+
+// Jaccard returns the Jaccard similarity of a and b, the size of their
+// intersection divided by the size of their union, without building either.
+// It is 1 if both sets are empty.
+//
+// Jaccard allocates nothing if both sets are map based sets of this package,
+// both are bit sets or both are roaring sets. Other sets are compared with
+// Each and Has.
+func Jaccard(a, b Interface) float64 {
+	first, second := rlockPair(a, b)
+	defer runlockPair(first, second)
+
+	u, v := view(a), view(b)
+	if u.Size() > v.Size() {
+		u, v = v, u
+	}
+	if v.Size() == 0 {
+		return 1
+	}
+
+	inter := intersectionSize(u, v)
+	return float64(inter) / float64(u.Size()+v.Size()-inter)
+}
+
+// intersectionSize returns the number of items of s which are also in t.
+// Neither set is locked.
+func intersectionSize(s, t Interface) int {
+	type mapSet interface {
+		items() map[interface{}]struct{}
+	}
+
+	n := 0
+	switch x := s.(type) {
+	case mapSet:
+		if y, ok := t.(mapSet); ok {
+			m := y.items()
+			for item := range x.items() {
+				if _, ok := m[item]; ok {
+					n++
+				}
+			}
+			return n
+		}
+
+	case interface{ bits() *bitSet }:
+		if y, ok := t.(interface{ bits() *bitSet }); ok {
+			xw, yw := x.bits().words, y.bits().words
+			for i := 0; i < len(xw) && i < len(yw); i++ {
+				n += bits.OnesCount64(xw[i] & yw[i])
+			}
+			return n
+		}
+
+	case interface{ roaring() *roaringSet }:
+		if y, ok := t.(interface{ roaring() *roaringSet }); ok {
+			return intersectionSizeRoaring(x.roaring(), y.roaring())
+		}
+	}
+
+	return intersectionSizeEach(s, t)
+}
+
+// intersectionSizeEach is intersectionSize for any sets. It is kept apart as
+// its closure moves the counter to the heap.
+func intersectionSizeEach(s, t Interface) int {
+	n := 0
+	s.Each(func(item interface{}) bool {
+		if t.Has(item) {
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+// intersectionSizeRoaring returns the number of items of a which are also in
+// b.
+func intersectionSizeRoaring(a, b *roaringSet) int {
+	n := 0
+	i, j := 0, 0
+	for i < len(a.keys) && j < len(b.keys) {
+		switch {
+		case a.keys[i] < b.keys[j]:
+			i++
+		case a.keys[i] > b.keys[j]:
+			j++
+		default:
+			n += intersectionSizeContainers(a.containers[i], b.containers[j])
+			i++
+			j++
+		}
+	}
+	return n
+}
+
+// intersectionSizeContainers returns the number of items of a which are also
+// in b.
+func intersectionSizeContainers(a, b container) int {
+	if _, ok := b.(*arrayContainer); ok {
+		a, b = b, a
+	}
+
+	n := 0
+	switch x := a.(type) {
+	case *arrayContainer:
+		for _, v := range x.values {
+			if b.has(v) {
+				n++
+			}
+		}
+
+	case *runContainer:
+		for _, r := range x.runs {
+			for v := int(r.start); v <= int(r.last); v++ {
+				if b.has(uint16(v)) {
+					n++
+				}
+			}
+		}
+
+	case *bitmapContainer:
+		if y, ok := b.(*bitmapContainer); ok {
+			for i, w := range x.words {
+				n += bits.OnesCount64(w & y.words[i])
+			}
+			return n
+		}
+		return intersectionSizeContainers(b, a)
+	}
+	return n
+}
@@ -0,0 +1,144 @@
+package set
+
+import (
+	"math"
+	"testing"
+)
+
+func TestJaccard(t *testing.T) {
+	tests := []struct {
+		a, b Interface
+		want float64
+	}{
+		{New(1, 2, 3), NewNonTS(2, 3, 4), 0.5},
+		{New(), New(), 1},
+		{New(1), NewNonTS(), 0},
+		{NewBitSet(1, 2, 3, 200), NewBitSetNonTS(2, 3, 4, 200), 0.6},
+		{NewRoaring(uint64(1), uint64(2)), NewRoaringNonTS(uint64(2), uint64(1)<<40), 1.0 / 3},
+		{NewOrdered("a", "b"), New("b", "c", "d"), 0.25},
+	}
+
+	for _, test := range tests {
+		if got := Jaccard(test.a, test.b); got != test.want {
+			t.Errorf("Jaccard(%v, %v) = %v, want %v", test.a, test.b, got, test.want)
+		}
+		if got := Jaccard(test.b, test.a); got != test.want {
+			t.Errorf("Jaccard(%v, %v) = %v, want %v", test.b, test.a, got, test.want)
+		}
+	}
+
+	s := New(1, 2)
+	if Jaccard(s, s) != 1 {
+		t.Error("Jaccard: should be 1 for the same set")
+	}
+}
+
+func TestJaccard_Roaring(t *testing.T) {
+	a, b := NewRoaringNonTS(), NewRoaringNonTS()
+	ma, mb := NewNonTS(), NewNonTS()
+	for i := uint64(0); i < 20000; i++ {
+		if i%3 == 0 {
+			a.AddUint64(i)
+			ma.Add(i)
+		}
+		if i%2 == 0 || i > 15000 {
+			b.AddUint64(i)
+			mb.Add(i)
+		}
+	}
+	b.RunOptimize()
+
+	if got, want := Jaccard(a, b), Jaccard(ma, mb); got != want {
+		t.Errorf("Jaccard: roaring sets give %v, map sets %v", got, want)
+	}
+}
+
+func TestJaccard_Allocs(t *testing.T) {
+	pairs := [][2]Interface{
+		{New("a", "b", "c"), New("b", "c", "d")},
+		{NewNonTS("a", "b", "c"), New("b", "c", "d")},
+		{NewBitSet(1, 2, 3), NewBitSet(2, 3, 4)},
+		{NewRoaring(uint64(1), uint64(5000)), NewRoaringNonTS(uint64(5000))},
+	}
+
+	for _, p := range pairs {
+		if n := testing.AllocsPerRun(100, func() { Jaccard(p[0], p[1]) }); n != 0 {
+			t.Errorf("Jaccard: %T and %T allocate %v times", p[0], p[1], n)
+		}
+	}
+}
+
+func TestMinHash_Similarity(t *testing.T) {
+	a, b := New(), New()
+	for i := 0; i < 1000; i++ {
+		a.Add(i)
+		b.Add(i + 500)
+	}
+
+	ma, mb := NewMinHash(a, 256), NewMinHash(b, 256)
+	got, err := ma.Similarity(mb)
+	if err != nil || math.Abs(got-Jaccard(a, b)) > 3/math.Sqrt(256) {
+		t.Errorf("Similarity: should be about %v, got %v, %v", Jaccard(a, b), got, err)
+	}
+
+	if s, _ := ma.Similarity(NewMinHash(a.Copy(), 256)); s != 1 {
+		t.Error("Similarity: should be 1 for the same items, got", s)
+	}
+
+	if _, err := ma.Similarity(NewMinHash(a, 128)); err != ErrIncompatible {
+		t.Error("Similarity: should fail for other sizes, got", err)
+	}
+}
+
+func TestLSH(t *testing.T) {
+	base := make([]int, 200)
+	for i := range base {
+		base[i] = i
+	}
+
+	// doc0 and doc1 are 90% similar, doc2 shares nothing with them
+	docs := []Interface{NewNonTS(), NewNonTS(), NewNonTS()}
+	for _, i := range base {
+		docs[0].Add(i)
+		docs[1].Add(i + 10)
+		docs[2].Add(i + 1000)
+	}
+
+	l := NewLSH(128, 0.5)
+	if bands, rows := l.Bands(); bands*rows > 128 || math.Abs(l.Threshold()-0.5) > 0.1 {
+		t.Errorf("NewLSH: %d bands of %d rows have threshold %v", bands, rows, l.Threshold())
+	}
+
+	for i, doc := range docs {
+		if err := l.Add(i, NewMinHash(doc, 128)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pairs := l.Pairs()
+	if len(pairs) != 1 || pairs[0] != [2]interface{}{0, 1} {
+		t.Error("Pairs: should find the similar documents only, got", pairs)
+	}
+
+	got, err := l.Candidates(NewMinHash(docs[1], 128))
+	if err != nil || len(got) != 2 || got[0] != 0 || got[1] != 1 {
+		t.Error("Candidates: got", got, err)
+	}
+
+	if err := l.Add(3, NewMinHash(docs[0], 64)); err != ErrIncompatible {
+		t.Error("Add: should fail for other sizes, got", err)
+	}
+}
+
+func BenchmarkJaccard(b *testing.B) {
+	x, y := NewNonTS(), NewNonTS()
+	for i := 0; i < 100; i++ {
+		x.Add(i)
+		y.Add(i + 50)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Jaccard(x, y)
+	}
+}
@@ -0,0 +1,204 @@
+package set
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// MarshalJSON implements json.Marshaler. The set is encoded as a JSON array
+// of its items in no particular order, see SortedJSON for a deterministic
+// encoding.
+func (s *set) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.List())
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It replaces the items of s with
+// the elements of a JSON array. Numbers are decoded as float64, as
+// encoding/json does for interface{} values. Objects and arrays cannot be set
+// items and produce an error, leaving s unchanged.
+func (s *set) UnmarshalJSON(data []byte) error {
+	m, err := unmarshalJSONItems(data)
+	if err != nil || m == nil {
+		return err
+	}
+
+	s.m = m
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. The set is encoded as a JSON array
+// of its items in no particular order, see SortedJSON for a deterministic
+// encoding.
+func (s *Set) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.List())
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It replaces the items of s with
+// the elements of a JSON array. Numbers are decoded as float64, as
+// encoding/json does for interface{} values. Objects and arrays cannot be set
+// items and produce an error, leaving s unchanged.
+func (s *Set) UnmarshalJSON(data []byte) error {
+	m, err := unmarshalJSONItems(data)
+	if err != nil || m == nil {
+		return err
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.m = m
+	s.shared.Store(false)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. The set is encoded as a JSON array
+// of its items in no particular order, see SortedJSON for a deterministic
+// encoding.
+func (s *setOf[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.List())
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It replaces the items of s with
+// the elements of a JSON array. An element that does not decode into T
+// produces an error, leaving s unchanged.
+func (s *setOf[T]) UnmarshalJSON(data []byte) error {
+	m, err := unmarshalJSONItemsOf[T](data)
+	if err != nil || m == nil {
+		return err
+	}
+
+	s.m = m
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. The set is encoded as a JSON array
+// of its items in no particular order, see SortedJSON for a deterministic
+// encoding.
+func (s *SetOf[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.List())
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It replaces the items of s with
+// the elements of a JSON array. An element that does not decode into T
+// produces an error, leaving s unchanged.
+func (s *SetOf[T]) UnmarshalJSON(data []byte) error {
+	m, err := unmarshalJSONItemsOf[T](data)
+	if err != nil || m == nil {
+		return err
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.m = m
+	return nil
+}
+
+// unmarshalJSONItems decodes a JSON array into the items of a set. It returns
+// a nil map for JSON null.
+func unmarshalJSONItems(data []byte) (map[interface{}]struct{}, error) {
+	var items []interface{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+	if items == nil {
+		return nil, nil
+	}
+
+	m := make(map[interface{}]struct{}, len(items))
+	for i, item := range items {
+		if !hashable(item) {
+			return nil, fmt.Errorf("set: JSON array element %d is a %s, which cannot be a set item: %w", i, jsonKind(item), checkHashable([]interface{}{item}))
+		}
+		m[item] = keyExists
+	}
+	return m, nil
+}
+
+// unmarshalJSONItemsOf decodes a JSON array into the items of a SetOf[T]. It
+// returns a nil map for JSON null.
+func unmarshalJSONItemsOf[T comparable](data []byte) (map[T]struct{}, error) {
+	var items []T
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+	if items == nil {
+		return nil, nil
+	}
+
+	m := make(map[T]struct{}, len(items))
+	for i, item := range items {
+		if !hashable(item) {
+			return nil, fmt.Errorf("set: JSON array element %d is a %s, which cannot be a set item: %w", i, jsonKind(item), checkHashable([]interface{}{item}))
+		}
+		m[item] = keyExists
+	}
+	return m, nil
+}
+
+// jsonKind names the JSON type item was decoded from.
+func jsonKind(item interface{}) string {
+	switch item.(type) {
+	case map[string]interface{}:
+		return "JSON object"
+	case []interface{}:
+		return "JSON array"
+	default:
+		return fmt.Sprintf("%T", item)
+	}
+}
+
+// SortedJSON wraps a set so that it is encoded as a JSON array with sorted
+// elements, which makes the output deterministic:
+//
+//	data, err := json.Marshal(set.SortedJSON(s))
+//
+// Numbers are sorted by value, all other elements by their encoding.
+func SortedJSON(s json.Marshaler) json.Marshaler {
+	return sortedJSON{s}
+}
+
+type sortedJSON struct {
+	s json.Marshaler
+}
+
+func (j sortedJSON) MarshalJSON() ([]byte, error) {
+	data, err := j.s.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(items, func(a, b int) bool {
+		return compareJSON(items[a], items[b]) < 0
+	})
+	return json.Marshal(items)
+}
+
+// compareJSON orders two encoded JSON values. Numbers compare by value, as
+// all numbers start with '-' or a digit they still form one block in byte
+// order with respect to the other values.
+func compareJSON(a, b []byte) int {
+	if isJSONNumber(a) && isJSONNumber(b) {
+		x, errX := strconv.ParseFloat(string(a), 64)
+		y, errY := strconv.ParseFloat(string(b), 64)
+		if errX == nil && errY == nil && x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return bytes.Compare(a, b)
+}
+
+func isJSONNumber(v []byte) bool {
+	return len(v) > 0 && (v[0] == '-' || v[0] >= '0' && v[0] <= '9')
+}
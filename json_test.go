@@ -0,0 +1,108 @@
+package set
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSet_JSON(t *testing.T) {
+	s := New("a", "b", 1.5, true, nil)
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal("MarshalJSON:", err)
+	}
+
+	u := New()
+	if err := json.Unmarshal(data, u); err != nil {
+		t.Fatal("UnmarshalJSON:", err)
+	}
+
+	if !u.IsEqual(s) {
+		t.Error("UnmarshalJSON: round trip lost items, got", u)
+	}
+
+	data, _ = json.Marshal(NewNonTS())
+	if string(data) != "[]" {
+		t.Error("MarshalJSON: empty set should be an empty array, got", string(data))
+	}
+
+	n := NewNonTS("old")
+	if err := json.Unmarshal([]byte(`["x", 2, "x"]`), n); err != nil {
+		t.Fatal("UnmarshalJSON:", err)
+	}
+	if !n.IsEqual(New("x", 2.0)) {
+		t.Error("UnmarshalJSON: should replace the items, numbers decode as float64, got", n)
+	}
+
+	var v struct{ Tags *Set }
+	if err := json.Unmarshal([]byte(`{"Tags": ["go", "json"]}`), &v); err != nil || !v.Tags.Has("go", "json") {
+		t.Error("UnmarshalJSON: should work for nested sets", err)
+	}
+}
+
+func TestSet_JSONUnhashable(t *testing.T) {
+	for _, input := range []string{`[1, {"a": 1}]`, `["x", [1, 2]]`} {
+		s := New("keep")
+		err := json.Unmarshal([]byte(input), s)
+		if err == nil || !strings.Contains(err.Error(), "cannot be a set item") {
+			t.Errorf("UnmarshalJSON: %s should produce an unhashable error, got %v", input, err)
+		}
+
+		if !s.IsEqual(New("keep")) {
+			t.Error("UnmarshalJSON: set should be unchanged after an error")
+		}
+	}
+
+	if err := json.Unmarshal([]byte(`{"a": 1}`), NewNonTS()); err == nil {
+		t.Error("UnmarshalJSON: a JSON object is not a set")
+	}
+}
+
+func TestSetOf_JSON(t *testing.T) {
+	s := NewOf(3, 1, 2)
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal("MarshalJSON:", err)
+	}
+
+	u := NewNonTSOf[int]()
+	if err := json.Unmarshal(data, u); err != nil || !u.IsEqual(s) {
+		t.Error("UnmarshalJSON: round trip lost items", err, u)
+	}
+
+	err = json.Unmarshal([]byte(`[1, "two"]`), u)
+	if _, ok := err.(*json.UnmarshalTypeError); !ok {
+		t.Error("UnmarshalJSON: wrong element type should produce an UnmarshalTypeError, got", err)
+	}
+
+	if !u.IsEqual(s) {
+		t.Error("UnmarshalJSON: set should be unchanged after an error")
+	}
+
+	if err := json.Unmarshal([]byte(`[[1]]`), NewOf[interface{}]()); err == nil {
+		t.Error("UnmarshalJSON: unhashable element should produce an error")
+	}
+}
+
+func TestSortedJSON(t *testing.T) {
+	s := New("b", 10, "a", 9, -1.5, true)
+
+	for i := 0; i < 5; i++ {
+		data, err := json.Marshal(SortedJSON(s))
+		if err != nil {
+			t.Fatal("SortedJSON:", err)
+		}
+
+		if string(data) != `["a","b",-1.5,9,10,true]` {
+			t.Error("SortedJSON: output should be sorted, got", string(data))
+		}
+	}
+
+	data, _ := json.Marshal(SortedJSON(NewOf("z", "y")))
+	if string(data) != `["y","z"]` {
+		t.Error("SortedJSON: should work for typed sets, got", string(data))
+	}
+}
@@ -0,0 +1,442 @@
+package set
+
+import (
+	"iter"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// KeyFunc maps an item to the key deciding its membership in a KeyedSet.
+// Items with the same key are the same member. Keys must be hashable.
+type KeyFunc func(item interface{}) interface{}
+
+// StringKey returns a KeyFunc which applies normalize to string items. Other
+// items are their own key.
+func StringKey(normalize func(string) string) KeyFunc {
+	return func(item interface{}) interface{} {
+		if s, ok := item.(string); ok {
+			return normalize(s)
+		}
+		return item
+	}
+}
+
+// FoldKey is a KeyFunc making strings which are equal under Unicode case
+// folding, as reported by strings.EqualFold, the same member.
+var FoldKey = StringKey(fold)
+
+// TrimKey is a KeyFunc making strings which are equal after trimming leading
+// and trailing white space the same member.
+var TrimKey = StringKey(strings.TrimSpace)
+
+// NFCKey is a KeyFunc making strings which are equal in Unicode normalization
+// form C the same member, such as a precomposed "é" and an "e" followed by a
+// combining acute accent.
+var NFCKey = StringKey(norm.NFC.String)
+
+// fold maps every rune of s to the smallest rune of its case folding orbit.
+func fold(s string) string {
+	return strings.Map(func(r rune) rune {
+		min := r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			if f < min {
+				min = f
+			}
+		}
+		return min
+	}, s)
+}
+
+// Provides a common baseline for both threadsafe and non-ts keyed sets. m
+// maps the key of every member to the item that was added first for it.
+type keyedSet struct {
+	m   map[interface{}]interface{}
+	key KeyFunc
+}
+
+// KeyedSetNonTS defines a non-thread safe set data structure whose membership
+// is decided by a KeyFunc. It stores the first item added for every key, so
+// List and Each return the original spelling while Has and Remove match any
+// item with the same key.
+type KeyedSetNonTS struct {
+	keyedSet
+}
+
+// KeyedSet defines a thread safe set data structure whose membership is
+// decided by a KeyFunc. It stores the first item added for every key, so List
+// and Each return the original spelling while Has and Remove match any item
+// with the same key.
+type KeyedSet struct {
+	keyedSet
+	setLock
+}
+
+// NewKeyedNonTS creates and initialize a new non-threadsafe KeyedSetNonTS
+// using key. It accepts a variable number of arguments to populate the
+// initial set.
+func NewKeyedNonTS(key KeyFunc, items ...interface{}) *KeyedSetNonTS {
+	s := &KeyedSetNonTS{}
+	s.key = key
+	s.m = make(map[interface{}]interface{})
+
+	// Ensure interface compliance
+	var _ Interface = s
+
+	s.Add(items...)
+	return s
+}
+
+// NewKeyed creates and initialize a new KeyedSet using key. It accepts a
+// variable number of arguments to populate the initial set.
+func NewKeyed(key KeyFunc, items ...interface{}) *KeyedSet {
+	s := &KeyedSet{}
+	s.key = key
+	s.m = make(map[interface{}]interface{})
+
+	// Ensure interface compliance
+	var _ Interface = s
+
+	s.Add(items...)
+	return s
+}
+
+// NewFolded creates a new KeyedSet of case-insensitive strings, "Foo.COM" and
+// "foo.com" are the same member.
+func NewFolded(items ...interface{}) *KeyedSet {
+	return NewKeyed(FoldKey, items...)
+}
+
+// NewTrimmed creates a new KeyedSet of strings which are compared without
+// their leading and trailing white space.
+func NewTrimmed(items ...interface{}) *KeyedSet {
+	return NewKeyed(TrimKey, items...)
+}
+
+// NewNFC creates a new KeyedSet of strings which are compared in Unicode
+// normalization form C, so composed and decomposed spellings are the same
+// member.
+func NewNFC(items ...interface{}) *KeyedSet {
+	return NewKeyed(NFCKey, items...)
+}
+
+// NewNormalized creates a new KeyedSet of strings which are compared after
+// applying normalize, such as another form of golang.org/x/text/unicode/norm.
+func NewNormalized(normalize func(string) string, items ...interface{}) *KeyedSet {
+	return NewKeyed(StringKey(normalize), items...)
+}
+
+// New creates and initalizes a new KeyedSetNonTS with the same KeyFunc.
+func (s *keyedSet) New(items ...interface{}) Interface {
+	return NewKeyedNonTS(s.key, items...)
+}
+
+// Add includes the specified items (one or more) to the set. Items whose key
+// is already in the set are ignored, the stored item is kept.
+func (s *keyedSet) Add(items ...interface{}) {
+	for _, item := range items {
+		k := s.key(item)
+		if _, ok := s.m[k]; !ok {
+			s.m[k] = item
+		}
+	}
+}
+
+// Remove deletes the items with the same keys as the specified items.
+func (s *keyedSet) Remove(items ...interface{}) {
+	for _, item := range items {
+		delete(s.m, s.key(item))
+	}
+}
+
+// Pop deletes and return an item from the set. If set is empty, nil is
+// returned.
+func (s *keyedSet) Pop() interface{} {
+	for k, item := range s.m {
+		delete(s.m, k)
+		return item
+	}
+	return nil
+}
+
+// Has looks for the existence of items with the same keys as the items
+// passed. It returns false if nothing is passed. For multiple items it
+// returns true only if all of the items exist.
+func (s *keyedSet) Has(items ...interface{}) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	for _, item := range items {
+		if _, has := s.m[s.key(item)]; !has {
+			return false
+		}
+	}
+	return true
+}
+
+// Get returns the stored item with the same key as item and whether there is
+// one.
+func (s *keyedSet) Get(item interface{}) (interface{}, bool) {
+	stored, ok := s.m[s.key(item)]
+	return stored, ok
+}
+
+// Size returns the number of items in a set.
+func (s *keyedSet) Size() int {
+	return len(s.m)
+}
+
+// Clear removes all items from the set.
+func (s *keyedSet) Clear() {
+	s.m = make(map[interface{}]interface{})
+}
+
+// IsEmpty reports whether the set is empty.
+func (s *keyedSet) IsEmpty() bool {
+	return s.Size() == 0
+}
+
+// IsEqual test whether s and t are the same in size and have the same items.
+func (s *keyedSet) IsEqual(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isEqual(s, view(t))
+}
+
+// IsSubset tests whether t is a subset of s.
+func (s *keyedSet) IsSubset(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isSubset(s, view(t))
+}
+
+// IsSuperset tests whether t is a superset of s.
+func (s *keyedSet) IsSuperset(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isSubset(view(t), s)
+}
+
+// Each traverses the stored items in the set, calling the provided function
+// for each set member. Traversal will continue until all items in the set
+// have been visited, or if the closure returns false.
+func (s *keyedSet) Each(f func(item interface{}) bool) {
+	for _, item := range s.m {
+		if !f(item) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over the items of the set, visiting them in the
+// same order as Each.
+func (s *keyedSet) All() iter.Seq[interface{}] {
+	return func(yield func(item interface{}) bool) {
+		s.Each(yield)
+	}
+}
+
+// String returns a string representation of s
+func (s *keyedSet) String() string {
+	return formatItems(s.List())
+}
+
+// List returns a slice of all stored items.
+func (s *keyedSet) List() []interface{} {
+	list := make([]interface{}, 0, len(s.m))
+	for _, item := range s.m {
+		list = append(list, item)
+	}
+	return list
+}
+
+// Copy returns a new KeyedSetNonTS with a copy of s.
+func (s *keyedSet) Copy() Interface {
+	return NewKeyedNonTS(s.key, s.List()...)
+}
+
+// Merge is like Union, however it modifies the current set it's applied on
+// with the given t set.
+func (s *keyedSet) Merge(t Interface) {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	s.Add(view(t).List()...)
+}
+
+// Separate removes the set items containing in t from set s. Please aware that
+// it's not the opposite of Merge.
+func (s *keyedSet) Separate(t Interface) {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	s.Remove(view(t).List()...)
+}
+
+// New creates and initalizes a new KeyedSet with the same KeyFunc.
+func (s *KeyedSet) New(items ...interface{}) Interface {
+	return NewKeyed(s.key, items...)
+}
+
+// Add includes the specified items (one or more) to the set. Items whose key
+// is already in the set are ignored, the stored item is kept.
+func (s *KeyedSet) Add(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.keyedSet.Add(items...)
+}
+
+// Remove deletes the items with the same keys as the specified items.
+func (s *KeyedSet) Remove(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.keyedSet.Remove(items...)
+}
+
+// Pop deletes and return an item from the set. If set is empty, nil is
+// returned.
+func (s *KeyedSet) Pop() interface{} {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return s.keyedSet.Pop()
+}
+
+// Has looks for the existence of items with the same keys as the items
+// passed. It returns false if nothing is passed. For multiple items it
+// returns true only if all of the items exist.
+func (s *KeyedSet) Has(items ...interface{}) bool {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.keyedSet.Has(items...)
+}
+
+// Get returns the stored item with the same key as item and whether there is
+// one.
+func (s *KeyedSet) Get(item interface{}) (interface{}, bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.keyedSet.Get(item)
+}
+
+// Size returns the number of items in a set.
+func (s *KeyedSet) Size() int {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return len(s.m)
+}
+
+// Clear removes all items from the set.
+func (s *KeyedSet) Clear() {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.keyedSet.Clear()
+}
+
+// IsEmpty reports whether the set is empty.
+func (s *KeyedSet) IsEmpty() bool {
+	return s.Size() == 0
+}
+
+// IsEqual test whether s and t are the same in size and have the same items.
+func (s *KeyedSet) IsEqual(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isEqual(&s.keyedSet, view(t))
+}
+
+// IsSubset tests whether t is a subset of s.
+func (s *KeyedSet) IsSubset(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isSubset(&s.keyedSet, view(t))
+}
+
+// IsSuperset tests whether t is a superset of s.
+func (s *KeyedSet) IsSuperset(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isSubset(view(t), &s.keyedSet)
+}
+
+// Each traverses the stored items in the set, calling the provided function
+// for each set member. Traversal will continue until all items in the set
+// have been visited, or if the closure returns false.
+func (s *KeyedSet) Each(f func(item interface{}) bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	s.keyedSet.Each(f)
+}
+
+// All returns an iterator over the items of the set, visiting them in the
+// same order as Each. As with Each, s is read locked from the start of the
+// loop until it ends, so the loop body must not modify s.
+func (s *KeyedSet) All() iter.Seq[interface{}] {
+	return func(yield func(item interface{}) bool) {
+		s.Each(yield)
+	}
+}
+
+// String returns a string representation of s
+func (s *KeyedSet) String() string {
+	return formatItems(s.List())
+}
+
+// List returns a slice of all stored items.
+func (s *KeyedSet) List() []interface{} {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.keyedSet.List()
+}
+
+// Copy returns a new KeyedSet with a copy of s.
+func (s *KeyedSet) Copy() Interface {
+	return NewKeyed(s.key, s.List()...)
+}
+
+// Merge is like Union, however it modifies the current set it's applied on
+// with the given t set.
+func (s *KeyedSet) Merge(t Interface) {
+	unlock := lockSets(s, t)
+	defer unlock()
+
+	s.keyedSet.Add(view(t).List()...)
+}
+
+// Separate removes the set items containing in t from set s. Please aware that
+// it's not the opposite of Merge.
+func (s *KeyedSet) Separate(t Interface) {
+	unlock := lockSets(s, t)
+	defer unlock()
+
+	s.keyedSet.Remove(view(t).List()...)
+}
+
+// unlocked returns the underlying keyed set of s, which does not lock.
+func (s *KeyedSet) unlocked() Interface {
+	return &s.keyedSet
+}
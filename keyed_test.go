@@ -0,0 +1,123 @@
+package set
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestKeyedSet_Folded(t *testing.T) {
+	for _, s := range []Interface{NewFolded(), NewKeyedNonTS(FoldKey)} {
+		s.Add("Foo.COM", "foo.com", "FOO.com", "bar.org")
+
+		if s.Size() != 2 || !s.Has("foo.COM", "BAR.ORG") {
+			t.Error("Add: case variants should be the same member, got", s)
+		}
+
+		if !NewNonTS(s.List()...).IsEqual(New("Foo.COM", "bar.org")) {
+			t.Error("List: should return the first spelling added, got", s.List())
+		}
+
+		s.Remove("BAR.org")
+		if s.Has("bar.org") || s.Size() != 1 {
+			t.Error("Remove: should remove by key")
+		}
+
+		if item := s.Pop(); item != "Foo.COM" || !s.IsEmpty() {
+			t.Error("Pop: should return the stored item, got", item)
+		}
+	}
+
+	// full Unicode case folding, not only ASCII
+	s := NewFolded("STRASSE", "Σίσυφος", "K")
+	if !s.Has("strasse", "ΣΊΣΥΦΟΣ", "\u212a") || s.Has("Kelvin") {
+		t.Error("FoldKey: should fold like strings.EqualFold")
+	}
+}
+
+func TestKeyedSet_Get(t *testing.T) {
+	s := NewTrimmed("  alice ", "bob", 42)
+
+	if item, ok := s.Get("alice"); !ok || item != "  alice " {
+		t.Error("Get: should return the stored item, got", item)
+	}
+
+	if _, ok := s.Get("carol"); ok {
+		t.Error("Get: should report missing items")
+	}
+
+	if !s.Has(" bob\t", 42) {
+		t.Error("TrimKey: should ignore white space and leave other items alone")
+	}
+}
+
+func TestKeyedSet_Normalized(t *testing.T) {
+	// a stand-in for norm.NFC.String composing "e" + combining acute accent
+	nfc := strings.NewReplacer("é", "é").Replace
+	s := NewNormalized(nfc, "café")
+
+	if !s.Has("café") || s.Size() != 1 {
+		t.Error("NewNormalized: normalized spellings should be the same member")
+	}
+}
+
+func TestKeyedSet_NFC(t *testing.T) {
+	composed, decomposed := "caf\u00e9", "cafe\u0301"
+
+	s := NewNFC(decomposed, "Café")
+	if !s.Has(composed) || s.Size() != 2 {
+		t.Error("NewNFC: composed and decomposed spellings should be the same member")
+	}
+	if v, _ := s.Get(composed); v != decomposed {
+		t.Errorf("Get: should return the item added first, got %q", v)
+	}
+
+	s.Remove(composed)
+	if s.Has(decomposed) {
+		t.Error("Remove: should remove the decomposed spelling too")
+	}
+}
+
+func TestKeyedSet_Operations(t *testing.T) {
+	a := NewFolded("A", "b", "C")
+	b := NewKeyedNonTS(FoldKey, "a", "B", "d")
+
+	if u := Union(a, b); u.Size() != 4 || !u.Has("a", "b", "c", "d") {
+		t.Error("Union: should keep the KeyFunc of the first set, got", u)
+	}
+
+	if i := Intersection(a, b); !NewNonTS(i.List()...).IsEqual(New("A", "b")) {
+		t.Error("Intersection: should keep the stored items of the first set, got", i)
+	}
+
+	if !a.IsSubset(New("c")) || a.IsEqual(b) || !a.Copy().IsEqual(a) {
+		t.Error("IsSubset: wrong result")
+	}
+
+	a.Merge(b)
+	a.Separate(New("c", "d"))
+	if !a.IsEqual(New("a", "b")) {
+		t.Error("Merge: wrong items after Merge and Separate", a)
+	}
+}
+
+func TestKeyedSet_Race(t *testing.T) {
+	s := NewFolded()
+	u := NewFolded()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				s.Add("Item", "ITEM")
+				u.Merge(s)
+				s.Get("item")
+				s.IsEqual(u)
+				s.Pop()
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,174 @@
+package set
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// lastLockID is the last id handed out by lockOrder.lockID.
+var lastLockID uint64
+
+// lockOrder is the position of a threadsafe set in the package wide lock
+// order. Operations that touch several sets lock them in ascending id order,
+// so two goroutines working on the same sets can never wait on each other.
+type lockOrder struct {
+	id uint64
+}
+
+// setLock is the lock of a threadsafe set together with its lock order.
+type setLock struct {
+	l sync.RWMutex // we name it because we don't want to expose it
+	lockOrder
+}
+
+// lockable is implemented by every threadsafe set of the package.
+type lockable interface {
+	lockID() uint64
+	lock(write bool)
+	unlock(write bool)
+}
+
+// lockID returns the id of the set, assigning one on first use.
+func (l *lockOrder) lockID() uint64 {
+	if id := atomic.LoadUint64(&l.id); id != 0 {
+		return id
+	}
+
+	atomic.CompareAndSwapUint64(&l.id, 0, atomic.AddUint64(&lastLockID, 1))
+	return atomic.LoadUint64(&l.id)
+}
+
+func (l *setLock) lock(write bool) {
+	if write {
+		l.l.Lock()
+	} else {
+		l.l.RLock()
+	}
+}
+
+func (l *setLock) unlock(write bool) {
+	if write {
+		l.l.Unlock()
+	} else {
+		l.l.RUnlock()
+	}
+}
+
+// lockSets locks w for writing and r for reading, in ascending lock id order,
+// and returns the function releasing them again. Arguments which are not
+// threadsafe sets, including nil, are ignored. A set that is passed more than
+// once is locked only once, for writing if it is w.
+func lockSets(w interface{}, r ...interface{}) (unlock func()) {
+	type held struct {
+		l     lockable
+		write bool
+	}
+
+	locks := make([]held, 0, len(r)+1)
+	add := func(set interface{}, write bool) {
+		l, ok := set.(lockable)
+		if !ok {
+			return
+		}
+
+		for i := range locks {
+			if locks[i].l.lockID() == l.lockID() {
+				locks[i].write = locks[i].write || write
+				return
+			}
+		}
+		locks = append(locks, held{l, write})
+	}
+
+	add(w, true)
+	for _, set := range r {
+		add(set, false)
+	}
+
+	sort.Slice(locks, func(i, j int) bool {
+		return locks[i].l.lockID() < locks[j].l.lockID()
+	})
+
+	for _, h := range locks {
+		h.l.lock(h.write)
+	}
+
+	return func() {
+		for i := len(locks) - 1; i >= 0; i-- {
+			locks[i].l.unlock(locks[i].write)
+		}
+	}
+}
+
+// lockInterfaces is lockSets for a slice of sets which are all read.
+func lockInterfaces(sets []Interface) (unlock func()) {
+	r := make([]interface{}, len(sets))
+	for i, set := range sets {
+		r[i] = set
+	}
+	return lockSets(nil, r...)
+}
+
+// lockInterfacesOf is lockSets for a slice of sets which are all read.
+func lockInterfacesOf[T comparable](sets []InterfaceOf[T]) (unlock func()) {
+	r := make([]interface{}, len(sets))
+	for i, set := range sets {
+		r[i] = set
+	}
+	return lockSets(nil, r...)
+}
+
+// view returns a version of t that does not lock. It must only be used while
+// t is locked with lockSets.
+func view(t Interface) Interface {
+	if conv, ok := t.(interface{ unlocked() Interface }); ok {
+		return conv.unlocked()
+	}
+	return t
+}
+
+// viewOf returns a version of t that does not lock. It must only be used while
+// t is locked with lockSets.
+func viewOf[T comparable](t InterfaceOf[T]) InterfaceOf[T] {
+	if conv, ok := t.(interface{ unlocked() InterfaceOf[T] }); ok {
+		return conv.unlocked()
+	}
+	return t
+}
+
+// rlockPair read locks the threadsafe sets among a and b in lock order, like
+// lockSets(nil, a, b) but without allocating. Pass its results to
+// runlockPair.
+func rlockPair(a, b interface{}) (first, second lockable) {
+	first, _ = a.(lockable)
+	second, _ = b.(lockable)
+
+	switch {
+	case first == nil:
+		first, second = second, nil
+	case second == nil:
+	case first.lockID() == second.lockID():
+		second = nil
+	case first.lockID() > second.lockID():
+		first, second = second, first
+	}
+
+	if first != nil {
+		first.lock(false)
+	}
+	if second != nil {
+		second.lock(false)
+	}
+	return first, second
+}
+
+// runlockPair unlocks the sets locked by rlockPair.
+func runlockPair(first, second lockable) {
+	if second != nil {
+		second.unlock(false)
+	}
+	if first != nil {
+		first.unlock(false)
+	}
+}
@@ -0,0 +1,224 @@
+package set
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// MinHash is a signature of a set: for each of k hash functions the smallest
+// hash of any item. The fraction of positions where two signatures agree is
+// an unbiased estimate of the Jaccard similarity of their sets, with a
+// standard error of about 1/sqrt(k).
+//
+// The hash functions are the same in every process, so signatures built
+// anywhere can be compared. A MinHash is never changed after it is built and
+// is safe for concurrent use.
+type MinHash struct {
+	sig []uint64
+}
+
+// minHashSeeds returns the seeds of the first k hash functions, generated
+// with splitmix64.
+func minHashSeeds(k int) []uint64 {
+	seeds := make([]uint64, k)
+	x := uint64(0)
+	for i := range seeds {
+		x += 0x9e3779b97f4a7c15
+		seeds[i] = mix64(x)
+	}
+	return seeds
+}
+
+// NewMinHash builds the signature of s with k hash functions, the
+// permutations of the items. k must be positive.
+func NewMinHash(s Interface, k int) *MinHash {
+	if k < 1 {
+		panic(fmt.Errorf("set: MinHash needs at least one permutation, got %d", k))
+	}
+
+	m := &MinHash{sig: make([]uint64, k)}
+	for i := range m.sig {
+		m.sig[i] = math.MaxUint64
+	}
+	seeds := minHashSeeds(k)
+
+	unlock := lockSets(nil, s)
+	defer unlock()
+
+	view(s).Each(func(item interface{}) bool {
+		h := hashItem(item)
+		for i, seed := range seeds {
+			m.sig[i] = min(m.sig[i], mix64(h^seed))
+		}
+		return true
+	})
+	return m
+}
+
+// Size returns the number of hash functions of m.
+func (m *MinHash) Size() int {
+	return len(m.sig)
+}
+
+// Similarity returns the estimated Jaccard similarity of the sets of m and t.
+// It returns ErrIncompatible unless both have the same number of hash
+// functions.
+func (m *MinHash) Similarity(t *MinHash) (float64, error) {
+	if len(m.sig) != len(t.sig) {
+		return 0, ErrIncompatible
+	}
+
+	equal := 0
+	for i, h := range m.sig {
+		if h == t.sig[i] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(m.sig)), nil
+}
+
+// LSH finds candidate pairs of similar sets among many MinHash signatures
+// without comparing all pairs. The signatures are cut into bands of rows;
+// two sets are a candidate pair if all rows of at least one band agree,
+// which is likely for sets above the threshold and unlikely below it.
+// Candidates should be confirmed with Similarity or Jaccard.
+//
+// It is safe for concurrent use.
+type LSH struct {
+	l       sync.RWMutex // we name it because we don't want to expose it
+	k       int
+	bands   int
+	rows    int
+	keys    []interface{}
+	buckets []map[uint64][]int // per band, band hash to indexes of keys
+}
+
+// NewLSH creates an LSH index for signatures with k hash functions. It picks
+// the bands and rows whose threshold, (1/bands)^(1/rows), is closest to
+// threshold.
+func NewLSH(k int, threshold float64) *LSH {
+	if k < 1 || !(threshold > 0 && threshold < 1) {
+		panic(fmt.Errorf("set: invalid LSH with %d hash functions and threshold %v", k, threshold))
+	}
+
+	bands, rows := k, 1
+	best := math.Inf(1)
+	for r := 1; r <= k; r++ {
+		b := k / r
+		if d := math.Abs(math.Pow(1/float64(b), 1/float64(r)) - threshold); d < best {
+			best, bands, rows = d, b, r
+		}
+	}
+
+	l := &LSH{k: k, bands: bands, rows: rows, buckets: make([]map[uint64][]int, bands)}
+	for i := range l.buckets {
+		l.buckets[i] = make(map[uint64][]int)
+	}
+	return l
+}
+
+// Bands returns the number of bands and rows per band of l.
+func (l *LSH) Bands() (bands, rows int) {
+	return l.bands, l.rows
+}
+
+// Threshold returns the similarity at which sets become candidate pairs
+// with a probability of about one half.
+func (l *LSH) Threshold() float64 {
+	return math.Pow(1/float64(l.bands), 1/float64(l.rows))
+}
+
+// bandHash returns the hash of band b of m.
+func (l *LSH) bandHash(m *MinHash, b int) uint64 {
+	h := uint64(b)
+	for _, v := range m.sig[b*l.rows : (b+1)*l.rows] {
+		h = mix64(h ^ v)
+	}
+	return h
+}
+
+// Add indexes the signature m under key, which identifies its set in the
+// results of Candidates and Pairs. It returns ErrIncompatible unless m has
+// the number of hash functions l was created for.
+func (l *LSH) Add(key interface{}, m *MinHash) error {
+	if len(m.sig) != l.k {
+		return ErrIncompatible
+	}
+
+	l.l.Lock()
+	defer l.l.Unlock()
+
+	l.keys = append(l.keys, key)
+	for b := range l.buckets {
+		h := l.bandHash(m, b)
+		l.buckets[b][h] = append(l.buckets[b][h], len(l.keys)-1)
+	}
+	return nil
+}
+
+// Candidates returns the keys of the indexed signatures sharing a band with
+// m, in the order they were added.
+func (l *LSH) Candidates(m *MinHash) ([]interface{}, error) {
+	if len(m.sig) != l.k {
+		return nil, ErrIncompatible
+	}
+
+	l.l.RLock()
+	defer l.l.RUnlock()
+
+	found := make(map[int]struct{})
+	for b := range l.buckets {
+		for _, i := range l.buckets[b][l.bandHash(m, b)] {
+			found[i] = keyExists
+		}
+	}
+
+	indexes := make([]int, 0, len(found))
+	for i := range found {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	keys := make([]interface{}, len(indexes))
+	for j, i := range indexes {
+		keys[j] = l.keys[i]
+	}
+	return keys, nil
+}
+
+// Pairs returns all candidate pairs among the indexed signatures. Each pair
+// is listed once, ordered by when its keys were added.
+func (l *LSH) Pairs() [][2]interface{} {
+	l.l.RLock()
+	defer l.l.RUnlock()
+
+	found := make(map[[2]int]struct{})
+	for _, buckets := range l.buckets {
+		for _, indexes := range buckets {
+			for x := 0; x < len(indexes); x++ {
+				for y := x + 1; y < len(indexes); y++ {
+					found[[2]int{indexes[x], indexes[y]}] = keyExists
+				}
+			}
+		}
+	}
+
+	ordered := make([][2]int, 0, len(found))
+	for p := range found {
+		ordered = append(ordered, p)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i][0] != ordered[j][0] {
+			return ordered[i][0] < ordered[j][0]
+		}
+		return ordered[i][1] < ordered[j][1]
+	})
+
+	pairs := make([][2]interface{}, len(ordered))
+	for i, p := range ordered {
+		pairs[i] = [2]interface{}{l.keys[p[0]], l.keys[p[1]]}
+	}
+	return pairs
+}
@@ -0,0 +1,462 @@
+package set
+
+import (
+	"iter"
+	"sync"
+	"sync/atomic"
+)
+
+// EventKind is the kind of change an Event reports.
+type EventKind int
+
+const (
+	// Added reports items which were added to the set.
+	Added EventKind = iota + 1
+
+	// Removed reports items which were removed from the set by Remove, Pop or
+	// Separate.
+	Removed
+
+	// Cleared reports the items which were removed from the set by Clear.
+	Cleared
+)
+
+// String returns the name of k.
+func (k EventKind) String() string {
+	switch k {
+	case Added:
+		return "Added"
+	case Removed:
+		return "Removed"
+	case Cleared:
+		return "Cleared"
+	}
+	return "EventKind(?)"
+}
+
+// Event is a change of an ObservableSet. Items are the items which were
+// actually added or removed by one call, so adding an item which is already
+// in the set reports nothing. Events are only sent for calls which changed
+// the set.
+type Event struct {
+	Kind  EventKind
+	Items []interface{}
+}
+
+// subscription is a subscriber of an ObservableSet, either a callback f or a
+// buffered channel ch.
+type subscription struct {
+	f    func(Event)
+	ch   chan Event
+	chl  sync.Mutex  // held while sending on or closing ch
+	dead atomic.Bool // cancelled, or ch overflowed and was closed
+}
+
+// delivery is an event waiting to be sent to the subscribers of the set at
+// the time of the change.
+type delivery struct {
+	e    Event
+	subs []*subscription
+}
+
+// ObservableSet defines a thread safe set data structure which reports every
+// change to its subscribers. Each call that modifies the set sends at most one
+// Event, so Merge and Separate report all items they added or removed in a
+// single event, and events are delivered in the order of the changes.
+//
+// Subscribers are either synchronous callbacks, see Subscribe, or buffered
+// channels, see SubscribeChan.
+type ObservableSet struct {
+	s set
+	setLock
+	subs []*subscription // replaced, never modified, under the write lock
+
+	// events are queued in the order of the changes, under the write lock,
+	// and sent by one goroutine at a time after s is unlocked again
+	ql         sync.Mutex // never held while calling subscribers
+	queue      []delivery
+	delivering bool
+}
+
+// NewObservable creates and initialize a new ObservableSet. It accepts a
+// variable number of arguments to populate the initial set, which are not
+// reported to anyone.
+func NewObservable(items ...interface{}) *ObservableSet {
+	s := &ObservableSet{}
+	s.s.m = make(map[interface{}]struct{})
+
+	// Ensure interface compliance
+	var _ Interface = s
+
+	s.s.add(items)
+	return s
+}
+
+// Subscribe calls f with every following change of s, until the returned
+// cancel function is called. f is called after s is unlocked again, by one
+// goroutine at a time: the goroutine that changed s, before that call
+// returns, unless another goroutine is sending events of s already, which
+// then sends this one as well. f may read and modify s and cancel
+// subscriptions. A slow f delays the goroutine sending the events and
+// following events, but not other writers of s.
+func (s *ObservableSet) Subscribe(f func(e Event)) (cancel func()) {
+	sub := &subscription{f: f}
+	s.subscribe(sub)
+
+	return func() {
+		s.unsubscribe(sub)
+	}
+}
+
+// SubscribeChan returns a channel which receives every following change of s,
+// buffering up to buffer events, until the returned cancel function is
+// called, which closes the channel.
+//
+// Writers of s never wait for the channel. If a change happens while the
+// buffer is full, the subscription is cancelled instead: the events in the
+// buffer can still be received and then the channel is closed. A subscriber
+// whose channel is closed without calling cancel has missed events and
+// should read s again and subscribe anew.
+func (s *ObservableSet) SubscribeChan(buffer int) (events <-chan Event, cancel func()) {
+	sub := &subscription{ch: make(chan Event, buffer)}
+	s.subscribe(sub)
+
+	return sub.ch, func() {
+		s.unsubscribe(sub)
+
+		sub.chl.Lock()
+		defer sub.chl.Unlock()
+
+		if !sub.dead.Swap(true) {
+			close(sub.ch)
+		}
+	}
+}
+
+func (s *ObservableSet) subscribe(sub *subscription) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.subs = append(s.live(), sub)
+}
+
+func (s *ObservableSet) unsubscribe(sub *subscription) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if sub.f != nil {
+		sub.dead.Store(true)
+	}
+
+	subs := make([]*subscription, 0, len(s.subs))
+	for _, other := range s.subs {
+		if other != sub {
+			subs = append(subs, other)
+		}
+	}
+	s.subs = subs
+}
+
+// live returns a new slice of the subscriptions of s which are not dead. s
+// must be write locked.
+func (s *ObservableSet) live() []*subscription {
+	subs := make([]*subscription, 0, len(s.subs)+1)
+	for _, sub := range s.subs {
+		if !sub.dead.Load() {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// notify queues the event of kind with items for the subscribers of s,
+// unless there are no items, and delivers the queued events. s must be write
+// locked; notify releases it with unlock before calling subscribers, and it
+// must not be unlocked again.
+func (s *ObservableSet) notify(kind EventKind, items []interface{}, unlock func()) {
+	if len(items) == 0 || len(s.subs) == 0 {
+		unlock()
+		return
+	}
+
+	s.ql.Lock()
+	s.queue = append(s.queue, delivery{Event{Kind: kind, Items: items}, s.subs})
+	s.ql.Unlock()
+
+	unlock()
+	s.deliver()
+}
+
+// deliver sends the queued events to their subscribers, unless another
+// goroutine is doing so already and sends them instead. s must not be
+// locked.
+func (s *ObservableSet) deliver() {
+	s.ql.Lock()
+	if s.delivering {
+		s.ql.Unlock()
+		return
+	}
+	s.delivering = true
+	s.ql.Unlock()
+
+	done := false
+	defer func() {
+		if !done { // a subscriber panicked, let the next change deliver
+			s.ql.Lock()
+			s.delivering = false
+			s.ql.Unlock()
+		}
+	}()
+
+	for queue := s.next(); queue != nil; queue = s.next() {
+		for _, d := range queue {
+			for _, sub := range d.subs {
+				sub.send(d.e)
+			}
+		}
+	}
+	done = true
+}
+
+// next takes the queued events of s. If there are none it returns nil and
+// ends the delivery.
+func (s *ObservableSet) next() []delivery {
+	s.ql.Lock()
+	defer s.ql.Unlock()
+
+	queue := s.queue
+	s.queue = nil
+	if len(queue) == 0 {
+		s.delivering = false
+		return nil
+	}
+	return queue
+}
+
+// send passes e to sub unless it is dead. A full channel is closed instead.
+func (sub *subscription) send(e Event) {
+	if sub.f != nil {
+		if !sub.dead.Load() {
+			sub.f(e)
+		}
+		return
+	}
+
+	sub.chl.Lock()
+	defer sub.chl.Unlock()
+
+	if sub.dead.Load() {
+		return
+	}
+
+	select {
+	case sub.ch <- e:
+	default:
+		sub.dead.Store(true)
+		close(sub.ch)
+	}
+}
+
+// New creates and initalizes a new ObservableSet without subscribers. It
+// accepts a variable number of arguments to populate the initial set.
+func (s *ObservableSet) New(items ...interface{}) Interface {
+	n := NewObservable()
+	n.s.validate = s.s.validate
+	n.Add(items...)
+	return n
+}
+
+// Add includes the specified items (one or more) to the set and reports the
+// items which were not in the set yet as one Added event.
+func (s *ObservableSet) Add(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.s.checkItems(items)
+
+	s.l.Lock()
+
+	var added []interface{}
+	for _, item := range items {
+		if _, ok := s.s.m[item]; !ok {
+			s.s.m[item] = keyExists
+			added = append(added, item)
+		}
+	}
+
+	s.notify(Added, added, s.l.Unlock)
+}
+
+// Remove deletes the specified items from the set and reports the items
+// which were in the set as one Removed event.
+func (s *ObservableSet) Remove(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+
+	var removed []interface{}
+	for _, item := range items {
+		if _, ok := s.s.m[item]; ok {
+			delete(s.s.m, item)
+			removed = append(removed, item)
+		}
+	}
+
+	s.notify(Removed, removed, s.l.Unlock)
+}
+
+// Pop deletes and return an item from the set and reports it as a Removed
+// event. If set is empty, nil is returned.
+func (s *ObservableSet) Pop() interface{} {
+	s.l.Lock()
+
+	if len(s.s.m) == 0 {
+		s.l.Unlock()
+		return nil
+	}
+
+	item := s.s.Pop()
+	s.notify(Removed, []interface{}{item}, s.l.Unlock)
+	return item
+}
+
+// Clear removes all items from the set and reports them as a Cleared event.
+func (s *ObservableSet) Clear() {
+	s.l.Lock()
+
+	removed := s.s.List()
+	s.s.m = make(map[interface{}]struct{})
+
+	s.notify(Cleared, removed, s.l.Unlock)
+}
+
+// Merge is like Union, however it modifies the current set it's applied on
+// with the given t set. The items of t which were not in s are reported as
+// one Added event.
+func (s *ObservableSet) Merge(t Interface) {
+	unlock := lockSets(s, t)
+
+	var added []interface{}
+	view(t).Each(func(item interface{}) bool {
+		if _, ok := s.s.m[item]; !ok {
+			s.s.m[item] = keyExists
+			added = append(added, item)
+		}
+		return true
+	})
+
+	s.notify(Added, added, unlock)
+}
+
+// Separate removes the set items containing in t from set s. The removed
+// items are reported as one Removed event.
+func (s *ObservableSet) Separate(t Interface) {
+	unlock := lockSets(s, t)
+
+	var removed []interface{}
+	view(t).Each(func(item interface{}) bool {
+		if _, ok := s.s.m[item]; ok {
+			delete(s.s.m, item)
+			removed = append(removed, item)
+		}
+		return true
+	})
+
+	s.notify(Removed, removed, unlock)
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (s *ObservableSet) Has(items ...interface{}) bool {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.s.Has(items...)
+}
+
+// Size returns the number of items in a set.
+func (s *ObservableSet) Size() int {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.s.Size()
+}
+
+// IsEmpty reports whether the set is empty.
+func (s *ObservableSet) IsEmpty() bool {
+	return s.Size() == 0
+}
+
+// IsEqual test whether s and t are the same in size and have the same items.
+func (s *ObservableSet) IsEqual(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isEqual(&s.s, view(t))
+}
+
+// IsSubset tests whether t is a subset of s.
+func (s *ObservableSet) IsSubset(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isSubset(&s.s, view(t))
+}
+
+// IsSuperset tests whether t is a superset of s.
+func (s *ObservableSet) IsSuperset(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isSubset(view(t), &s.s)
+}
+
+// Each traverses the items in the set, calling the provided function for each
+// set member. Traversal will continue until all items in the set have been
+// visited, or if the closure returns false. s is read locked during the
+// traversal, so the closure must not modify s.
+func (s *ObservableSet) Each(f func(item interface{}) bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	s.s.Each(f)
+}
+
+// All returns an iterator over the items of the set, visiting them in the
+// same order as Each. As with Each, s is read locked from the start of the
+// loop until it ends, so the loop body must not modify s.
+func (s *ObservableSet) All() iter.Seq[interface{}] {
+	return func(yield func(item interface{}) bool) {
+		s.Each(yield)
+	}
+}
+
+// String returns a string representation of s
+func (s *ObservableSet) String() string {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.s.String()
+}
+
+// List returns a slice of all items.
+func (s *ObservableSet) List() []interface{} {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.s.List()
+}
+
+// Copy returns a new ObservableSet without subscribers with a copy of s.
+func (s *ObservableSet) Copy() Interface {
+	return s.New(s.List()...)
+}
+
+// unlocked returns the underlying set of s, which does not lock. It is only
+// read, changes through it would not be reported.
+func (s *ObservableSet) unlocked() Interface {
+	return &s.s
+}
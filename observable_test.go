@@ -0,0 +1,256 @@
+package set
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recorder collects the events of an ObservableSet with sorted items.
+type recorder struct {
+	l      sync.Mutex
+	events []Event
+}
+
+func (r *recorder) record(e Event) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	items := append([]interface{}(nil), e.Items...)
+	sort.Slice(items, func(i, j int) bool { return items[i].(int) < items[j].(int) })
+	r.events = append(r.events, Event{e.Kind, items})
+}
+
+func formatEvents(events []Event) string {
+	s := ""
+	for _, e := range events {
+		s += e.Kind.String() + formatItems(e.Items) + " "
+	}
+	return s
+}
+
+func TestObservableSet_Subscribe(t *testing.T) {
+	s := NewObservable(1, 2)
+	r := &recorder{}
+	cancel := s.Subscribe(r.record)
+
+	s.Add(2, 3, 4)
+	s.Add(3)
+	s.Remove(1, 9)
+	s.Merge(New(4, 5, 6))
+	s.Separate(New(5, 6, 7))
+	s.Clear()
+	s.Clear()
+
+	want := "Added[3, 4] Removed[1] Added[5, 6] Removed[5, 6] Cleared[2, 3, 4] "
+	if got := formatEvents(r.events); got != want {
+		t.Errorf("Subscribe: got events %q, want %q", got, want)
+	}
+
+	cancel()
+	s.Add(1)
+	if len(r.events) != 5 {
+		t.Error("Subscribe: should not report changes after cancel")
+	}
+}
+
+func TestObservableSet_Pop(t *testing.T) {
+	s := NewObservable(1)
+	r := &recorder{}
+	s.Subscribe(r.record)
+
+	if s.Pop() != 1 || s.Pop() != nil {
+		t.Error("Pop: should return the items of the set")
+	}
+	if got := formatEvents(r.events); got != "Removed[1] " {
+		t.Error("Pop: should report the popped item, got", got)
+	}
+}
+
+func TestObservableSet_Subscribe_read(t *testing.T) {
+	s := NewObservable()
+
+	sizes := []int{}
+	var cancel func()
+	cancel = s.Subscribe(func(e Event) {
+		sizes = append(sizes, s.Size())
+		if s.Has(3) {
+			cancel()
+		}
+	})
+
+	for i := 1; i <= 5; i++ {
+		s.Add(i)
+	}
+	if len(sizes) != 3 || sizes[2] != 3 {
+		t.Error("Subscribe: callbacks should read the set and cancel themselves, got", sizes)
+	}
+}
+
+func TestObservableSet_Subscribe_concurrent(t *testing.T) {
+	s := NewObservable()
+
+	var reads atomic.Int64
+	s.Subscribe(func(e Event) {
+		// other writers wait for s meanwhile, which must not block reading
+		if s.Has(e.Items...) || s.Size() >= 0 {
+			reads.Add(1)
+		}
+	})
+
+	// callbacks may change s, the events follow in order
+	r := &recorder{}
+	s.Subscribe(r.record)
+	s.Subscribe(func(e Event) {
+		if e.Kind == Added && s.Has(-1) {
+			s.Remove(-1)
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < 200; i++ {
+					s.Add(g*1000 + i)
+					s.Remove(g*1000 + i)
+				}
+			}(g)
+		}
+		wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Subscribe: writers deadlocked with a callback reading the set")
+	}
+
+	if n := reads.Load(); n != 1600 {
+		t.Error("Subscribe: the callback should see every event, got", n)
+	}
+
+	r.events = nil
+	s.Add(-1)
+	if got := formatEvents(r.events); got != "Added[-1] Removed[-1] " || s.Has(-1) {
+		t.Error("Subscribe: a change by a callback should be reported after the event, got", got)
+	}
+}
+
+func TestObservableSet_SubscribeChan(t *testing.T) {
+	s := NewObservable()
+	events, cancel := s.SubscribeChan(2)
+
+	s.Add(1)
+	s.Remove(1)
+	if e := <-events; e.Kind != Added || e.Items[0] != 1 {
+		t.Error("SubscribeChan: should receive the Added event, got", e)
+	}
+	if e := <-events; e.Kind != Removed || e.Items[0] != 1 {
+		t.Error("SubscribeChan: should receive the Removed event, got", e)
+	}
+
+	cancel()
+	cancel()
+	if _, ok := <-events; ok {
+		t.Error("SubscribeChan: cancel should close the channel")
+	}
+	s.Add(2)
+}
+
+func TestObservableSet_SubscribeChan_overflow(t *testing.T) {
+	s := NewObservable()
+	events, cancel := s.SubscribeChan(2)
+	defer cancel()
+
+	other, cancelOther := s.SubscribeChan(10)
+	defer cancelOther()
+
+	for i := 0; i < 5; i++ {
+		s.Add(i)
+	}
+
+	n := 0
+	for range events {
+		n++
+	}
+	if n != 2 {
+		t.Error("SubscribeChan: should close the channel after the buffered events on overflow, got", n)
+	}
+	if len(other) != 5 {
+		t.Error("SubscribeChan: overflow should not affect other subscribers, got", len(other))
+	}
+}
+
+func TestObservableSet_Operations(t *testing.T) {
+	s := NewObservable(1, 2, 3)
+	if u := Union(s, New(4)); !u.IsEqual(New(1, 2, 3, 4)) {
+		t.Error("Union: should work with observable sets, got", u)
+	}
+	if _, ok := s.Copy().(*ObservableSet); !ok || !s.IsSubset(New(1)) || !s.IsSuperset(New(1, 2, 3, 4)) {
+		t.Error("ObservableSet: should implement Interface")
+	}
+}
+
+func TestObservableSet_Race(t *testing.T) {
+	s := NewObservable()
+
+	var l sync.Mutex
+	sizes := make(map[interface{}]int)
+	s.Subscribe(func(e Event) {
+		l.Lock()
+		defer l.Unlock()
+
+		for _, item := range e.Items {
+			if e.Kind == Added {
+				sizes[item]++
+			} else {
+				sizes[item]--
+			}
+		}
+	})
+	events, cancel := s.SubscribeChan(1 << 16)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				s.Add(i % 20)
+				s.Merge(New(i%7, g))
+				s.Remove(i % 13)
+				s.Pop()
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// replaying the events in order gives the final set
+	replay := NewNonTS()
+	for len(events) > 0 {
+		e := <-events
+		if e.Kind == Added {
+			replay.Add(e.Items...)
+		} else {
+			replay.Remove(e.Items...)
+		}
+	}
+	if !replay.IsEqual(s) {
+		t.Error("Subscribe: events should be delivered in the order of the changes")
+	}
+
+	for item, n := range sizes {
+		if n != 0 && n != 1 || (n == 1) != s.Has(item) {
+			t.Error("Subscribe: events should match the set, item", item, "counted", n)
+		}
+	}
+}
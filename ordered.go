@@ -0,0 +1,436 @@
+package set
+
+import (
+	"container/list"
+	"iter"
+)
+
+// Provides a common ordered set baseline for both threadsafe and non-ts
+// ordered sets. Items are kept in a linked list in insertion order, the map
+// points at their list elements.
+type orderedSet struct {
+	m     map[interface{}]*list.Element
+	order *list.List
+}
+
+// OrderedSetNonTS defines a non-thread safe set data structure that
+// remembers the order in which items were added. Each, List and String visit
+// the items in that order.
+type OrderedSetNonTS struct {
+	orderedSet
+}
+
+// OrderedSet defines a thread safe set data structure that remembers the
+// order in which items were added. Each, List and String visit the items in
+// that order.
+type OrderedSet struct {
+	orderedSet
+	setLock
+}
+
+// NewOrderedNonTS creates and initialize a new non-threadsafe OrderedSetNonTS.
+// It accepts a variable number of arguments to populate the initial set in
+// the given order.
+func NewOrderedNonTS(items ...interface{}) *OrderedSetNonTS {
+	s := &OrderedSetNonTS{}
+	s.init()
+
+	// Ensure interface compliance
+	var _ Interface = s
+
+	s.Add(items...)
+	return s
+}
+
+// NewOrdered creates and initialize a new OrderedSet. It accepts a variable
+// number of arguments to populate the initial set in the given order.
+func NewOrdered(items ...interface{}) *OrderedSet {
+	s := &OrderedSet{}
+	s.init()
+
+	// Ensure interface compliance
+	var _ Interface = s
+
+	s.Add(items...)
+	return s
+}
+
+func (s *orderedSet) init() {
+	s.m = make(map[interface{}]*list.Element)
+	s.order = list.New()
+}
+
+// New creates and initalizes a new OrderedSetNonTS.
+func (s *orderedSet) New(items ...interface{}) Interface {
+	return NewOrderedNonTS(items...)
+}
+
+// Add appends the specified items (one or more) to the set. Items which are
+// already in the set keep their position.
+func (s *orderedSet) Add(items ...interface{}) {
+	for _, item := range items {
+		if _, ok := s.m[item]; !ok {
+			s.m[item] = s.order.PushBack(item)
+		}
+	}
+}
+
+// Remove deletes the specified items from the set.
+func (s *orderedSet) Remove(items ...interface{}) {
+	for _, item := range items {
+		if e, ok := s.m[item]; ok {
+			s.order.Remove(e)
+			delete(s.m, item)
+		}
+	}
+}
+
+// Pop deletes and returns the oldest item of the set. If set is empty, nil is
+// returned.
+func (s *orderedSet) Pop() interface{} {
+	e := s.order.Front()
+	if e == nil {
+		return nil
+	}
+
+	s.order.Remove(e)
+	delete(s.m, e.Value)
+	return e.Value
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (s *orderedSet) Has(items ...interface{}) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	for _, item := range items {
+		if _, has := s.m[item]; !has {
+			return false
+		}
+	}
+	return true
+}
+
+// Size returns the number of items in a set.
+func (s *orderedSet) Size() int {
+	return len(s.m)
+}
+
+// Clear removes all items from the set.
+func (s *orderedSet) Clear() {
+	s.init()
+}
+
+// IsEmpty reports whether the set is empty.
+func (s *orderedSet) IsEmpty() bool {
+	return s.Size() == 0
+}
+
+// IsEqual test whether s and t are the same in size and have the same items.
+// The order of the items is not taken into account.
+func (s *orderedSet) IsEqual(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isEqual(s, view(t))
+}
+
+// IsSubset tests whether t is a subset of s.
+func (s *orderedSet) IsSubset(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isSubset(s, view(t))
+}
+
+// IsSuperset tests whether t is a superset of s.
+func (s *orderedSet) IsSuperset(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isSubset(view(t), s)
+}
+
+// Each traverses the items in insertion order, calling the provided function
+// for each set member. Traversal will continue until all items in the set
+// have been visited, or if the closure returns false.
+func (s *orderedSet) Each(f func(item interface{}) bool) {
+	for e := s.order.Front(); e != nil; e = e.Next() {
+		if !f(e.Value) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over the items of the set, visiting them in the
+// same order as Each.
+func (s *orderedSet) All() iter.Seq[interface{}] {
+	return func(yield func(item interface{}) bool) {
+		s.Each(yield)
+	}
+}
+
+// String returns a string representation of s in insertion order.
+func (s *orderedSet) String() string {
+	return formatItems(s.List())
+}
+
+// List returns a slice of all items in insertion order.
+func (s *orderedSet) List() []interface{} {
+	list := make([]interface{}, 0, len(s.m))
+	for e := s.order.Front(); e != nil; e = e.Next() {
+		list = append(list, e.Value)
+	}
+	return list
+}
+
+// Copy returns a new OrderedSetNonTS with a copy of s in the same order.
+func (s *orderedSet) Copy() Interface {
+	return NewOrderedNonTS(s.List()...)
+}
+
+// Merge is like Union, however it modifies the current set it's applied on
+// with the given t set. New items are appended in the order t visits them.
+func (s *orderedSet) Merge(t Interface) {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	s.Add(view(t).List()...)
+}
+
+// Separate removes the set items containing in t from set s. Please aware that
+// it's not the opposite of Merge.
+func (s *orderedSet) Separate(t Interface) {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	s.Remove(view(t).List()...)
+}
+
+// First returns the oldest item of the set, or nil if the set is empty.
+func (s *orderedSet) First() interface{} {
+	if e := s.order.Front(); e != nil {
+		return e.Value
+	}
+	return nil
+}
+
+// Last returns the newest item of the set, or nil if the set is empty.
+func (s *orderedSet) Last() interface{} {
+	if e := s.order.Back(); e != nil {
+		return e.Value
+	}
+	return nil
+}
+
+// IndexOf returns the position of item in insertion order, or -1 if item is
+// not in the set. It runs in linear time.
+func (s *orderedSet) IndexOf(item interface{}) int {
+	if _, ok := s.m[item]; !ok {
+		return -1
+	}
+
+	i := 0
+	for e := s.order.Front(); e.Value != item; e = e.Next() {
+		i++
+	}
+	return i
+}
+
+// MoveToEnd moves item to the end of the insertion order, as if it was
+// removed and added again. If item is not in the set it silently returns.
+func (s *orderedSet) MoveToEnd(item interface{}) {
+	if e, ok := s.m[item]; ok {
+		s.order.MoveToBack(e)
+	}
+}
+
+// New creates and initalizes a new OrderedSet.
+func (s *OrderedSet) New(items ...interface{}) Interface {
+	return NewOrdered(items...)
+}
+
+// Add appends the specified items (one or more) to the set. Items which are
+// already in the set keep their position.
+func (s *OrderedSet) Add(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.orderedSet.Add(items...)
+}
+
+// Remove deletes the specified items from the set.
+func (s *OrderedSet) Remove(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.orderedSet.Remove(items...)
+}
+
+// Pop deletes and returns the oldest item of the set. If set is empty, nil is
+// returned.
+func (s *OrderedSet) Pop() interface{} {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return s.orderedSet.Pop()
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (s *OrderedSet) Has(items ...interface{}) bool {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.orderedSet.Has(items...)
+}
+
+// Size returns the number of items in a set.
+func (s *OrderedSet) Size() int {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return len(s.m)
+}
+
+// Clear removes all items from the set.
+func (s *OrderedSet) Clear() {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.init()
+}
+
+// IsEmpty reports whether the set is empty.
+func (s *OrderedSet) IsEmpty() bool {
+	return s.Size() == 0
+}
+
+// IsEqual test whether s and t are the same in size and have the same items.
+// The order of the items is not taken into account.
+func (s *OrderedSet) IsEqual(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isEqual(&s.orderedSet, view(t))
+}
+
+// IsSubset tests whether t is a subset of s.
+func (s *OrderedSet) IsSubset(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isSubset(&s.orderedSet, view(t))
+}
+
+// IsSuperset tests whether t is a superset of s.
+func (s *OrderedSet) IsSuperset(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isSubset(view(t), &s.orderedSet)
+}
+
+// Each traverses the items in insertion order, calling the provided function
+// for each set member. Traversal will continue until all items in the set
+// have been visited, or if the closure returns false.
+func (s *OrderedSet) Each(f func(item interface{}) bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	s.orderedSet.Each(f)
+}
+
+// All returns an iterator over the items of the set, visiting them in the
+// same order as Each. As with Each, s is read locked from the start of the
+// loop until it ends, so the loop body must not modify s.
+func (s *OrderedSet) All() iter.Seq[interface{}] {
+	return func(yield func(item interface{}) bool) {
+		s.Each(yield)
+	}
+}
+
+// String returns a string representation of s in insertion order.
+func (s *OrderedSet) String() string {
+	return formatItems(s.List())
+}
+
+// List returns a slice of all items in insertion order.
+func (s *OrderedSet) List() []interface{} {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.orderedSet.List()
+}
+
+// Copy returns a new OrderedSet with a copy of s in the same order.
+func (s *OrderedSet) Copy() Interface {
+	return NewOrdered(s.List()...)
+}
+
+// Merge is like Union, however it modifies the current set it's applied on
+// with the given t set. New items are appended in the order t visits them.
+func (s *OrderedSet) Merge(t Interface) {
+	unlock := lockSets(s, t)
+	defer unlock()
+
+	s.orderedSet.Add(view(t).List()...)
+}
+
+// Separate removes the set items containing in t from set s. Please aware that
+// it's not the opposite of Merge.
+func (s *OrderedSet) Separate(t Interface) {
+	unlock := lockSets(s, t)
+	defer unlock()
+
+	s.orderedSet.Remove(view(t).List()...)
+}
+
+// First returns the oldest item of the set, or nil if the set is empty.
+func (s *OrderedSet) First() interface{} {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.orderedSet.First()
+}
+
+// Last returns the newest item of the set, or nil if the set is empty.
+func (s *OrderedSet) Last() interface{} {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.orderedSet.Last()
+}
+
+// IndexOf returns the position of item in insertion order, or -1 if item is
+// not in the set. It runs in linear time.
+func (s *OrderedSet) IndexOf(item interface{}) int {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.orderedSet.IndexOf(item)
+}
+
+// MoveToEnd moves item to the end of the insertion order, as if it was
+// removed and added again. If item is not in the set it silently returns.
+func (s *OrderedSet) MoveToEnd(item interface{}) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.orderedSet.MoveToEnd(item)
+}
+
+// unlocked returns the underlying ordered set of s, which does not lock.
+func (s *OrderedSet) unlocked() Interface {
+	return &s.orderedSet
+}
@@ -0,0 +1,116 @@
+package set
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestOrderedSet_Order(t *testing.T) {
+	for _, s := range []Interface{NewOrdered(), NewOrderedNonTS()} {
+		s.Add("c", "a", "b", "a")
+
+		if !reflect.DeepEqual(s.List(), []interface{}{"c", "a", "b"}) {
+			t.Error("List: items should be in insertion order, got", s.List())
+		}
+
+		if s.String() != "[c, a, b]" {
+			t.Error("String: items should be in insertion order, got", s.String())
+		}
+
+		var each []interface{}
+		s.Each(func(item interface{}) bool {
+			each = append(each, item)
+			return len(each) < 2
+		})
+		if !reflect.DeepEqual(each, []interface{}{"c", "a"}) {
+			t.Error("Each: items should be visited in insertion order, got", each)
+		}
+
+		s.Remove("a")
+		s.Add("a")
+		if !reflect.DeepEqual(s.List(), []interface{}{"c", "b", "a"}) {
+			t.Error("Add: re-added item should go to the end, got", s.List())
+		}
+
+		if item := s.Pop(); item != "c" || s.Has("c") || s.Size() != 2 {
+			t.Error("Pop: should remove and return the oldest item, got", item)
+		}
+
+		if !s.Copy().IsEqual(s) || !reflect.DeepEqual(s.Copy().List(), s.List()) {
+			t.Error("Copy: copy should keep the order")
+		}
+
+		s.Clear()
+		if !s.IsEmpty() || s.Pop() != nil {
+			t.Error("Clear: set should be empty")
+		}
+	}
+}
+
+func TestOrderedSet_FirstLastIndexOf(t *testing.T) {
+	s := NewOrdered(3, 1, 2)
+
+	if s.First() != 3 || s.Last() != 2 {
+		t.Error("First/Last: wrong items", s.First(), s.Last())
+	}
+
+	if s.IndexOf(3) != 0 || s.IndexOf(2) != 2 || s.IndexOf(42) != -1 {
+		t.Error("IndexOf: wrong positions")
+	}
+
+	s.MoveToEnd(3)
+	s.MoveToEnd(42)
+	if !reflect.DeepEqual(s.List(), []interface{}{1, 2, 3}) {
+		t.Error("MoveToEnd: item should be moved to the end, got", s.List())
+	}
+
+	u := NewOrderedNonTS()
+	if u.First() != nil || u.Last() != nil {
+		t.Error("First/Last: should return nil on an empty set")
+	}
+}
+
+func TestOrderedSet_MergeSeparate(t *testing.T) {
+	s := NewOrdered(1, 2)
+	s.Merge(NewOrderedNonTS(3, 2, 4))
+
+	if !reflect.DeepEqual(s.List(), []interface{}{1, 2, 3, 4}) {
+		t.Error("Merge: new items should be appended in order, got", s.List())
+	}
+
+	s.Separate(New(2, 3))
+	if !reflect.DeepEqual(s.List(), []interface{}{1, 4}) {
+		t.Error("Separate: wrong items left, got", s.List())
+	}
+
+	if !s.IsEqual(New(4, 1)) || !s.IsSubset(New(1)) || !s.IsSuperset(New(1, 4, 5)) {
+		t.Error("IsEqual: order should not matter for comparisons")
+	}
+
+	if _, ok := Union(s, New(5)).(*OrderedSet); !ok {
+		t.Error("Union should derive its set type from the first passed set")
+	}
+}
+
+func TestOrderedSet_Race(t *testing.T) {
+	s := NewOrdered()
+	u := NewOrdered()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				s.Add(i)
+				s.MoveToEnd(i)
+				u.Merge(s)
+				s.IndexOf(i)
+				s.IsEqual(u)
+				s.Pop()
+			}
+		}(g)
+	}
+	wg.Wait()
+}
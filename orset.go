@@ -0,0 +1,495 @@
+package set
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+)
+
+// ErrCausalGap is returned by ORSet.ImportDelta for a delta which builds on
+// changes the set has not merged yet.
+var ErrCausalGap = errors.New("set: delta depends on changes which were not merged yet")
+
+// VersionVector holds, for every replica, the number of its changes which
+// were merged. Missing replicas count as zero.
+type VersionVector map[string]uint64
+
+// has reports whether v covers d.
+func (v VersionVector) has(d dot) bool {
+	return d.counter <= v[d.replica]
+}
+
+// covers reports whether v covers every change w covers.
+func (v VersionVector) covers(w VersionVector) bool {
+	for replica, n := range w {
+		if v[replica] < n {
+			return false
+		}
+	}
+	return true
+}
+
+// merge raises v to w wherever w is larger.
+func (v VersionVector) merge(w VersionVector) {
+	for replica, n := range w {
+		v[replica] = max(v[replica], n)
+	}
+}
+
+// StableVersion returns the pointwise minimum of the versions of all
+// replicas, the changes every replica has merged. Pass it to ORSet.Collect.
+func StableVersion(versions ...VersionVector) VersionVector {
+	stable := make(VersionVector)
+	if len(versions) == 0 {
+		return stable
+	}
+
+	for replica, n := range versions[0] {
+		for _, v := range versions[1:] {
+			n = min(n, v[replica])
+		}
+		if n > 0 {
+			stable[replica] = n
+		}
+	}
+	return stable
+}
+
+// dot identifies one change of an ORSet: the counter-th change of replica.
+type dot struct {
+	replica string
+	counter uint64
+}
+
+// ORSet is an observed-remove set, a set CRDT whose items can be removed and
+// added again any number of times. Every add is tagged with a new dot, a
+// replica id and counter. Remove removes the dots of the item it observed,
+// so an add which is concurrent to a remove survives the merge: adds win.
+//
+// Replicas exchange either their whole state with Merge, or only their
+// recent changes with DeltaSince and ImportDelta. Both are commutative,
+// associative and idempotent, so replicas converge in any delivery order.
+//
+// Removed dots are kept as tombstones, so that replicas which did not see the
+// remove yet cannot bring the item back. Collect drops the tombstones once
+// every replica has seen their remove.
+//
+// It is safe for concurrent use.
+type ORSet struct {
+	id      string
+	entries map[interface{}]map[dot]struct{} // the live dots of every item
+	owners  map[dot]interface{}              // the item of every live dot
+	tombs   map[dot]dot                      // removed dot to the change removing it
+	version VersionVector                    // the changes merged into s
+	floor   VersionVector                    // the changes whose tombstones were collected
+	setLock
+}
+
+// NewORSet creates and initialize a new ORSet for the replica with the given
+// id, which must be unique among all replicas, and adds items.
+func NewORSet(replica string, items ...interface{}) *ORSet {
+	if replica == "" {
+		panic(fmt.Errorf("set: ORSet needs a replica id"))
+	}
+
+	s := &ORSet{
+		id:      replica,
+		entries: make(map[interface{}]map[dot]struct{}),
+		owners:  make(map[dot]interface{}),
+		tombs:   make(map[dot]dot),
+		version: make(VersionVector),
+		floor:   make(VersionVector),
+	}
+
+	for _, item := range items {
+		s.add(item)
+	}
+	return s
+}
+
+// next returns the dot of a new change of s.
+func (s *ORSet) next() dot {
+	s.version[s.id]++
+	return dot{s.id, s.version[s.id]}
+}
+
+// add adds item with a new dot, which replaces the dots item had.
+func (s *ORSet) add(item interface{}) {
+	d := s.next()
+	s.removeDots(item, d)
+
+	s.entries[item] = map[dot]struct{}{d: keyExists}
+	s.owners[d] = item
+}
+
+// removeDots turns the dots of item into tombstones removed by change r.
+func (s *ORSet) removeDots(item interface{}, r dot) {
+	for d := range s.entries[item] {
+		s.tombs[d] = r
+		delete(s.owners, d)
+	}
+	delete(s.entries, item)
+}
+
+// addDot adds the dot d of item from another replica, unless it was removed.
+func (s *ORSet) addDot(item interface{}, d dot) {
+	if _, ok := s.owners[d]; ok {
+		return
+	}
+	if _, ok := s.tombs[d]; ok {
+		return
+	}
+	if s.floor.has(d) {
+		// s merged d before, so it was removed and its tombstone collected
+		return
+	}
+
+	if s.entries[item] == nil {
+		s.entries[item] = make(map[dot]struct{})
+	}
+	s.entries[item][d] = keyExists
+	s.owners[d] = item
+}
+
+// removeDot records that d was removed by change r of another replica.
+func (s *ORSet) removeDot(d, r dot) {
+	if item, ok := s.owners[d]; ok {
+		delete(s.owners, d)
+		if delete(s.entries[item], d); len(s.entries[item]) == 0 {
+			delete(s.entries, item)
+		}
+	}
+	if !s.floor.has(r) {
+		s.tombs[d] = r
+	}
+}
+
+// ID returns the replica id of s.
+func (s *ORSet) ID() string {
+	return s.id
+}
+
+// Add includes the specified items (one or more) to the set.
+func (s *ORSet) Add(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	for _, item := range items {
+		s.add(item)
+	}
+}
+
+// Remove deletes the specified items from the set. Adds of the items on other
+// replicas which were not merged into s yet are not affected.
+func (s *ORSet) Remove(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	for _, item := range items {
+		if _, ok := s.entries[item]; ok {
+			s.removeDots(item, s.next())
+		}
+	}
+}
+
+// Merge merges the state of t into s, afterwards s holds the items added on
+// either replica which were not removed after the add was observed.
+func (s *ORSet) Merge(t *ORSet) {
+	unlock := lockSets(s, t)
+	defer unlock()
+
+	s.merge(t.owners, t.tombs, t.version)
+}
+
+func (s *ORSet) merge(owners map[dot]interface{}, tombs map[dot]dot, version VersionVector) {
+	for d, r := range tombs {
+		s.removeDot(d, r)
+	}
+	for d, item := range owners {
+		s.addDot(item, d)
+	}
+	s.version.merge(version)
+}
+
+// Version returns the changes merged into s.
+func (s *ORSet) Version() VersionVector {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return maps.Clone(s.version)
+}
+
+// ORSetDelta holds the changes of an ORSet after some version, see
+// ORSet.DeltaSince. It can be encoded as JSON.
+type ORSetDelta struct {
+	since   VersionVector
+	version VersionVector
+	owners  map[dot]interface{}
+	tombs   map[dot]dot
+}
+
+// DeltaSince returns the changes of s which are not covered by v, usually
+// the Version of the replica the delta is sent to. DeltaSince(nil) returns
+// the whole state of s.
+func (s *ORSet) DeltaSince(v VersionVector) *ORSetDelta {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	delta := &ORSetDelta{
+		since:   make(VersionVector),
+		version: maps.Clone(s.version),
+		owners:  make(map[dot]interface{}),
+		tombs:   make(map[dot]dot),
+	}
+	for replica, n := range v {
+		if n = min(n, s.version[replica]); n > 0 {
+			delta.since[replica] = n
+		}
+	}
+
+	for d, item := range s.owners {
+		if !v.has(d) {
+			delta.owners[d] = item
+		}
+	}
+	for d, r := range s.tombs {
+		if !v.has(r) {
+			delta.tombs[d] = r
+		}
+	}
+	return delta
+}
+
+// ImportDelta merges the changes of d into s. It returns ErrCausalGap if d
+// builds on changes which s has not merged yet; the sender should then send
+// a delta since the Version of s.
+func (s *ORSet) ImportDelta(d *ORSetDelta) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if !s.version.covers(d.since) {
+		return ErrCausalGap
+	}
+
+	s.merge(d.owners, d.tombs, d.version)
+	return nil
+}
+
+// Collect drops the tombstones of the removes covered by stable, which must
+// only cover changes every replica has merged, such as the StableVersion of
+// the Versions of all replicas. It returns the number of dropped tombstones.
+//
+// States and deltas of other replicas that are older than stable can still
+// be merged afterwards without bringing removed items back.
+func (s *ORSet) Collect(stable VersionVector) int {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	n := 0
+	for d, r := range s.tombs {
+		if stable.has(r) {
+			delete(s.tombs, d)
+			n++
+		}
+	}
+	s.floor.merge(stable)
+	return n
+}
+
+// Tombstones returns the number of removed dots s keeps.
+func (s *ORSet) Tombstones() int {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return len(s.tombs)
+}
+
+// Copy returns a new ORSet with a copy of s, including its replica id. The
+// copy is meant to be sent to other replicas, not to make changes itself.
+func (s *ORSet) Copy() *ORSet {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	c := NewORSet(s.id)
+	for item, dots := range s.entries {
+		c.entries[item] = maps.Clone(dots)
+	}
+	c.owners = maps.Clone(s.owners)
+	c.tombs = maps.Clone(s.tombs)
+	c.version = maps.Clone(s.version)
+	c.floor = maps.Clone(s.floor)
+	return c
+}
+
+// Value returns a read-only copy of the items of s at this moment, which
+// implements Interface.
+func (s *ORSet) Value() *SetSnapshot {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	m := make(map[interface{}]struct{}, len(s.entries))
+	for item := range s.entries {
+		m[item] = keyExists
+	}
+	return &SetSnapshot{s: set{m: m}}
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (s *ORSet) Has(items ...interface{}) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	for _, item := range items {
+		if _, ok := s.entries[item]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Size returns the number of items in the set.
+func (s *ORSet) Size() int {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return len(s.entries)
+}
+
+// IsEmpty reports whether the set is empty.
+func (s *ORSet) IsEmpty() bool {
+	return s.Size() == 0
+}
+
+// IsEqual test whether s and t are the same in size and have the same items.
+func (s *ORSet) IsEqual(t Interface) bool {
+	return s.Value().IsEqual(t)
+}
+
+// IsSubset tests whether t is a subset of s.
+func (s *ORSet) IsSubset(t Interface) bool {
+	return s.Value().IsSubset(t)
+}
+
+// IsSuperset tests whether t is a superset of s.
+func (s *ORSet) IsSuperset(t Interface) bool {
+	return s.Value().IsSuperset(t)
+}
+
+// Each traverses the items in the set, calling the provided function for each
+// set member. Traversal will continue until all items in the set have been
+// visited, or if the closure returns false. s is read locked during the
+// traversal, so the closure must not modify s.
+func (s *ORSet) Each(f func(item interface{}) bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	for item := range s.entries {
+		if !f(item) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over the items of the set, visiting them in the
+// same order as Each. As with Each, s is read locked from the start of the
+// loop until it ends, so the loop body must not modify s.
+func (s *ORSet) All() iter.Seq[interface{}] {
+	return func(yield func(item interface{}) bool) {
+		s.Each(yield)
+	}
+}
+
+// List returns a slice of all items.
+func (s *ORSet) List() []interface{} {
+	return s.Value().List()
+}
+
+// String returns a string representation of s
+func (s *ORSet) String() string {
+	return s.Value().String()
+}
+
+// dotJSON is the JSON encoding of a dot.
+type dotJSON struct {
+	Replica string `json:"replica"`
+	Counter uint64 `json:"counter"`
+}
+
+// orSetDeltaJSON is the JSON encoding of an ORSetDelta.
+type orSetDeltaJSON struct {
+	Since   VersionVector     `json:"since"`
+	Version VersionVector     `json:"version"`
+	Adds    []orSetAddJSON    `json:"adds"`
+	Removes []orSetRemoveJSON `json:"removes"`
+}
+
+// orSetAddJSON is a live dot of an item in an orSetDeltaJSON.
+type orSetAddJSON struct {
+	Item interface{} `json:"item"`
+	Dot  dotJSON     `json:"dot"`
+}
+
+// orSetRemoveJSON is a tombstone in an orSetDeltaJSON.
+type orSetRemoveJSON struct {
+	Dot dotJSON `json:"dot"`
+	By  dotJSON `json:"by"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d *ORSetDelta) MarshalJSON() ([]byte, error) {
+	enc := orSetDeltaJSON{Since: d.since, Version: d.version}
+	for dt, item := range d.owners {
+		enc.Adds = append(enc.Adds, orSetAddJSON{item, dotJSON{dt.replica, dt.counter}})
+	}
+	for dt, r := range d.tombs {
+		enc.Removes = append(enc.Removes, orSetRemoveJSON{dotJSON{dt.replica, dt.counter}, dotJSON{r.replica, r.counter}})
+	}
+	return json.Marshal(enc)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Items are decoded as by
+// Set.UnmarshalJSON. On error d is unchanged.
+func (d *ORSetDelta) UnmarshalJSON(data []byte) error {
+	var enc orSetDeltaJSON
+	if err := json.Unmarshal(data, &enc); err != nil {
+		return err
+	}
+
+	owners := make(map[dot]interface{}, len(enc.Adds))
+	for i, add := range enc.Adds {
+		if !hashable(add.Item) {
+			return fmt.Errorf("set: ORSet delta item %d is a %s, which cannot be a set item: %w", i, jsonKind(add.Item), checkHashable([]interface{}{add.Item}))
+		}
+		owners[dot{add.Dot.Replica, add.Dot.Counter}] = add.Item
+	}
+
+	tombs := make(map[dot]dot, len(enc.Removes))
+	for _, rm := range enc.Removes {
+		tombs[dot{rm.Dot.Replica, rm.Dot.Counter}] = dot{rm.By.Replica, rm.By.Counter}
+	}
+
+	if enc.Since == nil {
+		enc.Since = make(VersionVector)
+	}
+	if enc.Version == nil {
+		enc.Version = make(VersionVector)
+	}
+
+	d.since, d.version, d.owners, d.tombs = enc.Since, enc.Version, owners, tombs
+	return nil
+}
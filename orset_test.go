@@ -0,0 +1,255 @@
+package set
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestORSet_addWins(t *testing.T) {
+	a := NewORSet("a", "x", "y")
+	b := NewORSet("b")
+	b.Merge(a)
+
+	// a removes x while b adds it again concurrently
+	a.Remove("x")
+	b.Add("x")
+	b.Remove("y")
+
+	a.Merge(b)
+	b.Merge(a)
+	for _, s := range []*ORSet{a, b} {
+		if !s.Has("x") || s.Has("y") || s.Size() != 1 {
+			t.Error("Merge: concurrent adds should win over removes, got", s)
+		}
+	}
+
+	// a remove that observed the add wins
+	a.Remove("x")
+	b.Merge(a)
+	if b.Has("x") {
+		t.Error("Merge: should apply removes of observed adds, got", b)
+	}
+
+	b.Add("x")
+	a.Merge(b)
+	if !a.Has("x") {
+		t.Error("Add: should add removed items again, got", a)
+	}
+}
+
+func TestORSet_Merge_convergence(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		replicas := []*ORSet{NewORSet("a"), NewORSet("b"), NewORSet("c")}
+		checkConvergence(t, seed, replicas, func(rnd *rand.Rand, r *ORSet) {
+			if rnd.Intn(3) == 0 {
+				r.Remove(rnd.Intn(20))
+			} else {
+				r.Add(rnd.Intn(20))
+			}
+		})
+
+		rnd := rand.New(rand.NewSource(seed))
+		states := []*ORSet{NewORSet("a"), NewORSet("b"), NewORSet("c")}
+		for _, s := range states {
+			for j := 0; j < 10; j++ {
+				s.Add(rnd.Intn(10))
+				s.Remove(rnd.Intn(10))
+			}
+		}
+		states[1].Merge(states[0])
+		states[1].Remove(rnd.Intn(10))
+		checkMergeLaws(t, states[0], states[1], states[2])
+	}
+}
+
+// TestORSet_ImportDelta_convergence exchanges only deltas since the version
+// of the receiver, delivered late and more than once, and collects the
+// tombstones of stable removes on the way.
+func TestORSet_ImportDelta_convergence(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		rnd := rand.New(rand.NewSource(seed))
+		replicas := []*ORSet{NewORSet("a"), NewORSet("b"), NewORSet("c")}
+
+		type message struct {
+			to    *ORSet
+			delta *ORSetDelta
+		}
+		var inFlight []message
+		for step := 0; step < 1000; step++ {
+			r := replicas[rnd.Intn(len(replicas))]
+			switch rnd.Intn(5) {
+			case 0:
+				r.Add(rnd.Intn(20))
+			case 1:
+				r.Remove(rnd.Intn(20))
+			case 2:
+				to := replicas[rnd.Intn(len(replicas))]
+				inFlight = append(inFlight, message{to, r.DeltaSince(to.Version())})
+			case 3:
+				if len(inFlight) > 0 {
+					m := inFlight[rnd.Intn(len(inFlight))]
+					if err := m.to.ImportDelta(m.delta); err != nil {
+						t.Fatal("ImportDelta: should accept deltas since the receiver's version, got", err)
+					}
+				}
+			default:
+				stable := StableVersion(replicas[0].Version(), replicas[1].Version(), replicas[2].Version())
+				r.Collect(stable)
+			}
+		}
+
+		for _, from := range replicas {
+			for _, to := range replicas {
+				if err := to.ImportDelta(from.DeltaSince(to.Version())); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		// old deltas arriving late change nothing
+		for _, m := range inFlight {
+			if err := m.to.ImportDelta(m.delta); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		want := replicas[0].Value()
+		for i, r := range replicas {
+			if got := r.Value(); !got.IsEqual(want) {
+				t.Fatalf("ImportDelta: seed %d replica %d has %v, want %v", seed, i, got, want)
+			}
+		}
+	}
+}
+
+func TestORSet_DeltaSince(t *testing.T) {
+	a, b := NewORSet("a"), NewORSet("b")
+	a.Add(1, 2, 3)
+	if err := b.ImportDelta(a.DeltaSince(b.Version())); err != nil {
+		t.Fatal(err)
+	}
+
+	a.Remove(1)
+	a.Add(4)
+	delta := a.DeltaSince(b.Version())
+	if len(delta.owners) != 1 || len(delta.tombs) != 1 {
+		t.Error("DeltaSince: should only hold the changes after the version, got", delta.owners, delta.tombs)
+	}
+
+	if err := b.ImportDelta(delta); err != nil || !b.Value().IsEqual(New(2, 3, 4)) {
+		t.Error("ImportDelta: should apply the changes, got", b, err)
+	}
+
+	// a delta since a version c has not reached
+	c := NewORSet("c")
+	a.Add(5)
+	if err := c.ImportDelta(a.DeltaSince(b.Version())); !errors.Is(err, ErrCausalGap) || !c.IsEmpty() {
+		t.Error("ImportDelta: should reject a delta with a causal gap, got", err)
+	}
+	if err := c.ImportDelta(a.DeltaSince(nil)); err != nil || !c.IsEqual(New(2, 3, 4, 5)) {
+		t.Error("DeltaSince: nil should export the whole state, got", c, err)
+	}
+}
+
+func TestORSet_Collect(t *testing.T) {
+	a, b := NewORSet("a"), NewORSet("b")
+	a.Add("x", "y")
+	b.Merge(a)
+	stale := b.Copy()
+
+	b.Remove("x")
+	a.Merge(b)
+	if a.Tombstones() != 1 || b.Tombstones() != 1 {
+		t.Fatal("Remove: should keep a tombstone, got", a.Tombstones())
+	}
+
+	// nothing is stable while replica c has not merged anything
+	a.Add("z")
+	b.Add("w")
+	if n := a.Collect(StableVersion(a.Version(), NewORSet("c").Version())); n != 0 {
+		t.Error("Collect: should keep tombstones of removes which are not stable, dropped", n)
+	}
+
+	stable := StableVersion(a.Version(), b.Version())
+	if a.Collect(stable) != 1 || b.Collect(stable) != 1 || a.Tombstones() != 0 {
+		t.Error("Collect: should drop tombstones of stable removes")
+	}
+
+	// the stale state still has the removed add of x
+	a.Merge(stale)
+	if err := b.ImportDelta(stale.DeltaSince(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if a.Has("x") || b.Has("x") || !a.Has("y", "z") {
+		t.Error("Collect: merging old states should not bring removed items back, got", a, b)
+	}
+}
+
+func TestORSetDelta_JSON(t *testing.T) {
+	a, b := NewORSet("a"), NewORSet("b")
+	a.Add("x", "y", 1.5)
+	a.Remove("y")
+
+	data, err := json.Marshal(a.DeltaSince(b.Version()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var delta ORSetDelta
+	if err := json.Unmarshal(data, &delta); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.ImportDelta(&delta); err != nil || !b.IsEqual(New("x", 1.5)) {
+		t.Error("UnmarshalJSON: should decode the delta, got", b, err)
+	}
+	if b.Version()["a"] != 4 {
+		t.Error("UnmarshalJSON: should decode the version, got", b.Version())
+	}
+
+	bad := []byte(`{"adds":[{"item":{"k":1},"dot":{"replica":"a","counter":1}}]}`)
+	if err := json.Unmarshal(bad, &delta); err == nil {
+		t.Error("UnmarshalJSON: should reject items which cannot be set items")
+	}
+}
+
+func TestORSet_Interface(t *testing.T) {
+	s := NewORSet("a", 1, 2, 3)
+	if !s.IsSubset(New(1, 2)) || !s.IsSuperset(New(1, 2, 3, 4)) || !s.IsEqual(NewNonTS(1, 2, 3)) {
+		t.Error("ORSet: should provide the read methods of Interface")
+	}
+	if len(s.List()) != 3 || s.ID() != "a" {
+		t.Error("List: should return all items")
+	}
+	if u := Union(s.Value(), New(4)); !u.IsEqual(New(1, 2, 3, 4)) {
+		t.Error("Value: should be usable as an Interface, got", u)
+	}
+}
+
+func TestORSet_Race(t *testing.T) {
+	a, b := NewORSet("a"), NewORSet("b")
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 300; i++ {
+				a.Add(i % 10)
+				b.Remove(i % 7)
+				a.Merge(b)
+				b.Merge(a)
+				_ = b.ImportDelta(a.DeltaSince(b.Version()))
+				a.Collect(StableVersion(a.Version(), b.Version()))
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	a.Merge(b)
+	b.Merge(a)
+	if !a.Value().IsEqual(b.Value()) {
+		t.Error("Merge: replicas should converge")
+	}
+}
@@ -0,0 +1,466 @@
+package set
+
+import (
+	"hash/maphash"
+	"iter"
+	"math/bits"
+)
+
+// The hash array mapped trie behind PersistentSet consumes the item hash
+// hamtBits at a time, so every node has up to 1<<hamtBits slots.
+const (
+	hamtBits = 5
+	hamtMask = 1<<hamtBits - 1
+)
+
+// persistentSeed seeds the hashes of all PersistentSets, so that versions
+// built independently of each other still share their trie layout for Diff.
+var persistentSeed = maphash.MakeSeed()
+
+// hamtEntry is an occupied slot of a hamtNode: either an item with its hash
+// or, if child is not nil, the subtrie of the items sharing the slot.
+type hamtEntry struct {
+	item  interface{}
+	hash  uint64
+	child *hamtNode
+}
+
+// hamtNode is a node of the trie. The bits of bitmap mark the occupied slots,
+// whose entries are stored in slot order. Nodes below the last hash bits hold
+// items with fully colliding hashes; they have no bitmap and are searched
+// linearly. Nodes are never modified once they are reachable from a set.
+//
+// A subtrie always holds at least two items, so a set of items has exactly
+// one trie, up to the order of colliding items.
+type hamtNode struct {
+	bitmap  uint32
+	entries []hamtEntry
+}
+
+// PersistentSet defines an immutable set data structure. With and Without
+// return new versions of the set in O(log n), sharing all unchanged parts of
+// the trie with the version they are called on, instead of copying it like
+// Copy does. Diff between two versions skips the shared parts.
+//
+// A PersistentSet is never modified and is safe for concurrent use without
+// locking. The zero value is an empty set.
+type PersistentSet struct {
+	root *hamtNode
+	size int
+}
+
+// NewPersistent creates a new PersistentSet. It accepts a variable number of
+// arguments to populate the initial set.
+func NewPersistent(items ...interface{}) *PersistentSet {
+	return (&PersistentSet{}).With(items...)
+}
+
+// hashPersistent returns the hash of item in the trie.
+func hashPersistent(item interface{}) uint64 {
+	switch v := item.(type) {
+	case string:
+		return maphash.String(persistentSeed, v)
+	case int:
+		return maphash.Comparable(persistentSeed, v)
+	default:
+		return maphash.Comparable(persistentSeed, item)
+	}
+}
+
+// slot returns the bit of the slot of h at shift and the index of the entry
+// for that slot in n.
+func (n *hamtNode) slot(h uint64, shift uint) (bit uint32, i int) {
+	bit = 1 << ((h >> shift) & hamtMask)
+	return bit, bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+// has reports whether n holds item with hash h.
+func (n *hamtNode) has(item interface{}, h uint64, shift uint) bool {
+	for n != nil {
+		if shift >= 64 {
+			for _, e := range n.entries {
+				if e.item == item {
+					return true
+				}
+			}
+			return false
+		}
+
+		bit, i := n.slot(h, shift)
+		if n.bitmap&bit == 0 {
+			return false
+		}
+
+		e := n.entries[i]
+		if e.child == nil {
+			return e.hash == h && e.item == item
+		}
+		n, shift = e.child, shift+hamtBits
+	}
+	return false
+}
+
+// with returns a trie holding the items of n and item, and whether item was
+// added. n is returned unchanged if it already holds item.
+func (n *hamtNode) with(item interface{}, h uint64, shift uint) (*hamtNode, bool) {
+	if shift >= 64 {
+		for _, e := range n.entries {
+			if e.item == item {
+				return n, false
+			}
+		}
+		return &hamtNode{entries: append(n.entries[:len(n.entries):len(n.entries)], hamtEntry{item: item, hash: h})}, true
+	}
+
+	bit, i := n.slot(h, shift)
+	if n.bitmap&bit == 0 {
+		c := &hamtNode{bitmap: n.bitmap | bit, entries: make([]hamtEntry, len(n.entries)+1)}
+		copy(c.entries, n.entries[:i])
+		c.entries[i] = hamtEntry{item: item, hash: h}
+		copy(c.entries[i+1:], n.entries[i:])
+		return c, true
+	}
+
+	e := n.entries[i]
+	var child *hamtNode
+	switch {
+	case e.child != nil:
+		var added bool
+		if child, added = e.child.with(item, h, shift+hamtBits); !added {
+			return n, false
+		}
+	case e.hash == h && e.item == item:
+		return n, false
+	default:
+		// two items share the slot, push both down into a new subtrie
+		child, _ = (&hamtNode{}).with(e.item, e.hash, shift+hamtBits)
+		child, _ = child.with(item, h, shift+hamtBits)
+	}
+
+	return n.replace(i, hamtEntry{child: child}), true
+}
+
+// without returns a trie holding the items of n except item, and whether
+// item was removed. The result is nil if it would be empty.
+func (n *hamtNode) without(item interface{}, h uint64, shift uint) (*hamtNode, bool) {
+	if shift >= 64 {
+		for i, e := range n.entries {
+			if e.item == item {
+				return n.remove(0, i), true
+			}
+		}
+		return n, false
+	}
+
+	bit, i := n.slot(h, shift)
+	if n.bitmap&bit == 0 {
+		return n, false
+	}
+
+	e := n.entries[i]
+	if e.child == nil {
+		if e.hash != h || e.item != item {
+			return n, false
+		}
+		return n.remove(bit, i), true
+	}
+
+	child, removed := e.child.without(item, h, shift+hamtBits)
+	if !removed {
+		return n, false
+	}
+
+	// a subtrie left with a single item is replaced by that item
+	if len(child.entries) == 1 && child.entries[0].child == nil {
+		return n.replace(i, child.entries[0]), true
+	}
+	return n.replace(i, hamtEntry{child: child}), true
+}
+
+// replace returns a copy of n with entry i replaced by e.
+func (n *hamtNode) replace(i int, e hamtEntry) *hamtNode {
+	c := &hamtNode{bitmap: n.bitmap, entries: make([]hamtEntry, len(n.entries))}
+	copy(c.entries, n.entries)
+	c.entries[i] = e
+	return c
+}
+
+// remove returns a copy of n without entry i of slot bit, or nil if it would
+// be empty.
+func (n *hamtNode) remove(bit uint32, i int) *hamtNode {
+	if len(n.entries) == 1 {
+		return nil
+	}
+
+	c := &hamtNode{bitmap: n.bitmap &^ bit, entries: make([]hamtEntry, 0, len(n.entries)-1)}
+	c.entries = append(c.entries, n.entries[:i]...)
+	c.entries = append(c.entries, n.entries[i+1:]...)
+	return c
+}
+
+// each calls f for every item of n until f returns false, which each
+// reports by returning false.
+func (n *hamtNode) each(f func(item interface{}) bool) bool {
+	if n == nil {
+		return true
+	}
+
+	for _, e := range n.entries {
+		if e.child != nil {
+			if !e.child.each(f) {
+				return false
+			}
+		} else if !f(e.item) {
+			return false
+		}
+	}
+	return true
+}
+
+// diffTries calls removed for the items of a missing in b and added for the
+// items of b missing in a, both at shift. Subtries shared by a and b are
+// skipped.
+func diffTries(a, b *hamtNode, shift uint, added, removed func(item interface{})) {
+	switch {
+	case a == b:
+		return
+	case a == nil || b == nil || shift >= 64:
+		diffItems(a, b, shift, added, removed)
+		return
+	}
+
+	i, j := 0, 0
+	for slots := a.bitmap | b.bitmap; slots != 0; slots &= slots - 1 {
+		bit := slots & -slots
+
+		var x, y *hamtEntry
+		if a.bitmap&bit != 0 {
+			x = &a.entries[i]
+			i++
+		}
+		if b.bitmap&bit != 0 {
+			y = &b.entries[j]
+			j++
+		}
+
+		switch {
+		case x == nil:
+			diffEntries(nil, y, shift+hamtBits, added, removed)
+		case y == nil:
+			diffEntries(x, nil, shift+hamtBits, added, removed)
+		case x.child != nil && y.child != nil:
+			diffTries(x.child, y.child, shift+hamtBits, added, removed)
+		case x.child == nil && y.child == nil:
+			if x.hash != y.hash || x.item != y.item {
+				removed(x.item)
+				added(y.item)
+			}
+		default:
+			diffEntries(x, y, shift+hamtBits, added, removed)
+		}
+	}
+}
+
+// diffEntries is diffTries for two entries of the same slot, of which at
+// most one holds a subtrie.
+func diffEntries(x, y *hamtEntry, shift uint, added, removed func(item interface{})) {
+	diffEntry(x, y, shift, removed)
+	diffEntry(y, x, shift, added)
+}
+
+// diffEntry calls f for the items of x which are not in y.
+func diffEntry(x, y *hamtEntry, shift uint, f func(item interface{})) {
+	switch {
+	case x == nil:
+	case x.child != nil:
+		x.child.each(func(item interface{}) bool {
+			if !y.has(item, hashPersistent(item), shift) {
+				f(item)
+			}
+			return true
+		})
+	case !y.has(x.item, x.hash, shift):
+		f(x.item)
+	}
+}
+
+// has reports whether the entry e, which may be nil, holds item with hash h.
+func (e *hamtEntry) has(item interface{}, h uint64, shift uint) bool {
+	switch {
+	case e == nil:
+		return false
+	case e.child != nil:
+		return e.child.has(item, h, shift)
+	default:
+		return e.hash == h && e.item == item
+	}
+}
+
+// diffItems is diffTries for tries which do not share their layout, it
+// looks up every item of either trie in the other.
+func diffItems(a, b *hamtNode, shift uint, added, removed func(item interface{})) {
+	a.each(func(item interface{}) bool {
+		if !b.has(item, hashPersistent(item), shift) {
+			removed(item)
+		}
+		return true
+	})
+	b.each(func(item interface{}) bool {
+		if !a.has(item, hashPersistent(item), shift) {
+			added(item)
+		}
+		return true
+	})
+}
+
+// With returns a version of s which also holds the specified items. s itself
+// is not changed. If all items are already in s, s is returned.
+func (s *PersistentSet) With(items ...interface{}) *PersistentSet {
+	root, size := s.root, s.size
+	if root == nil && len(items) > 0 {
+		root = &hamtNode{}
+	}
+
+	for _, item := range items {
+		var added bool
+		if root, added = root.with(item, hashPersistent(item), 0); added {
+			size++
+		}
+	}
+
+	if root == s.root {
+		return s
+	}
+	return &PersistentSet{root: root, size: size}
+}
+
+// Without returns a version of s without the specified items. s itself is
+// not changed. If none of the items are in s, s is returned.
+func (s *PersistentSet) Without(items ...interface{}) *PersistentSet {
+	root, size := s.root, s.size
+	for _, item := range items {
+		if root == nil {
+			break
+		}
+
+		var removed bool
+		if root, removed = root.without(item, hashPersistent(item), 0); removed {
+			size--
+		}
+	}
+
+	if root == s.root {
+		return s
+	}
+	return &PersistentSet{root: root, size: size}
+}
+
+// Diff returns the items which are in t but not in s, and the items which
+// are in s but not in t. Parts of the trie that t shares with s, because one
+// was derived from the other, are not visited, so the diff of two versions
+// costs about as much as the With and Without calls between them.
+func (s *PersistentSet) Diff(t *PersistentSet) (added, removed []interface{}) {
+	diffTries(s.root, t.root, 0,
+		func(item interface{}) { added = append(added, item) },
+		func(item interface{}) { removed = append(removed, item) })
+	return added, removed
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (s *PersistentSet) Has(items ...interface{}) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	for _, item := range items {
+		if !s.root.has(item, hashPersistent(item), 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// Size returns the number of items in the set.
+func (s *PersistentSet) Size() int {
+	return s.size
+}
+
+// IsEmpty reports whether the set is empty.
+func (s *PersistentSet) IsEmpty() bool {
+	return s.size == 0
+}
+
+// IsEqual tests whether s and t are the same in size and have the same items.
+func (s *PersistentSet) IsEqual(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	u := view(t)
+	return s.size == u.Size() && s.hasAll(u)
+}
+
+// IsSubset tests whether t is a subset of s.
+func (s *PersistentSet) IsSubset(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return s.hasAll(view(t))
+}
+
+// IsSuperset tests whether t is a superset of s.
+func (s *PersistentSet) IsSuperset(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	u := view(t)
+	return s.root.each(func(item interface{}) bool {
+		return u.Has(item)
+	})
+}
+
+// hasAll reports whether s holds every item of t, which is not locked.
+func (s *PersistentSet) hasAll(t Interface) bool {
+	subset := true
+	t.Each(func(item interface{}) bool {
+		subset = s.Has(item)
+		return subset
+	})
+	return subset
+}
+
+// Each traverses the items in the set, calling the provided function for each
+// set member. Traversal will continue until all items in the set have been
+// visited, or if the closure returns false.
+func (s *PersistentSet) Each(f func(item interface{}) bool) {
+	s.root.each(f)
+}
+
+// All returns an iterator over the items of the set, visiting them in the
+// same order as Each.
+func (s *PersistentSet) All() iter.Seq[interface{}] {
+	return func(yield func(item interface{}) bool) {
+		s.Each(yield)
+	}
+}
+
+// String returns a string representation of s
+func (s *PersistentSet) String() string {
+	return formatItems(s.List())
+}
+
+// List returns a slice of all items.
+func (s *PersistentSet) List() []interface{} {
+	list := make([]interface{}, 0, s.size)
+	s.Each(func(item interface{}) bool {
+		list = append(list, item)
+		return true
+	})
+	return list
+}
+
+// Copy returns a new non-threadsafe Set with the items of s, for use where an
+// Interface is needed.
+func (s *PersistentSet) Copy() Interface {
+	return NewNonTS(s.List()...)
+}
@@ -0,0 +1,223 @@
+package set
+
+import (
+	"math/rand"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func sortedItemInts(items []interface{}) []int {
+	ints := make([]int, len(items))
+	for i, item := range items {
+		ints[i] = item.(int)
+	}
+	sort.Ints(ints)
+	return ints
+}
+
+// TestPersistentSet_Versions applies random changes to a PersistentSet and a
+// SetNonTS and checks that every old version is unchanged.
+func TestPersistentSet_Versions(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	versions := []*PersistentSet{{}}
+	models := []Interface{NewNonTS()}
+	for i := 0; i < 2000; i++ {
+		p, m := versions[len(versions)-1], models[len(models)-1].Copy()
+
+		item := rnd.Intn(500)
+		if rnd.Intn(3) == 0 {
+			p = p.Without(item)
+			m.Remove(item)
+		} else {
+			p = p.With(item)
+			m.Add(item)
+		}
+		versions = append(versions, p)
+		models = append(models, m)
+	}
+
+	for i, p := range versions {
+		if p.Size() != models[i].Size() || !p.IsEqual(models[i]) {
+			t.Fatalf("With: version %d is %v, want %v", i, p, models[i])
+		}
+		if !slices.Equal(sortedItemInts(p.List()), sortedItemInts(models[i].List())) {
+			t.Fatalf("List: version %d lists %v, want %v", i, p.List(), models[i].List())
+		}
+	}
+}
+
+func TestPersistentSet_With(t *testing.T) {
+	p := NewPersistent("a", "b", 1)
+	q := p.With("c", "a")
+
+	if p.Size() != 3 || p.Has("c") {
+		t.Error("With: should not change the set it is called on")
+	}
+	if q.Size() != 4 || !q.Has("a", "b", 1, "c") {
+		t.Error("With: should add the items, got", q)
+	}
+	if p.With("a", 1) != p {
+		t.Error("With: should return the set itself if nothing is added")
+	}
+
+	var zero PersistentSet
+	if !zero.IsEmpty() || zero.Has("a") || zero.Without("a") != &zero {
+		t.Error("PersistentSet: the zero value should be an empty set")
+	}
+}
+
+func TestPersistentSet_Without(t *testing.T) {
+	p := NewPersistent("a", "b", "c")
+	q := p.Without("a", "z")
+
+	if p.Size() != 3 || !p.Has("a") {
+		t.Error("Without: should not change the set it is called on")
+	}
+	if q.Size() != 2 || q.Has("a") || !q.Has("b", "c") {
+		t.Error("Without: should remove the items, got", q)
+	}
+	if q.Without("z") != q {
+		t.Error("Without: should return the set itself if nothing is removed")
+	}
+	if r := q.Without("b", "c"); !r.IsEmpty() || r.Has("b") {
+		t.Error("Without: should remove all items, got", r)
+	}
+}
+
+func TestPersistentSet_Diff(t *testing.T) {
+	base := NewPersistent()
+	for i := 0; i < 10000; i++ {
+		base = base.With(i)
+	}
+
+	next := base.With(-1, -2, 5).Without(7, 9000, 123456)
+	added, removed := base.Diff(next)
+	if !slices.Equal(sortedItemInts(added), []int{-2, -1}) || !slices.Equal(sortedItemInts(removed), []int{7, 9000}) {
+		t.Error("Diff: should return the added and removed items, got", added, removed)
+	}
+
+	added, removed = next.Diff(base)
+	if !slices.Equal(sortedItemInts(added), []int{7, 9000}) || !slices.Equal(sortedItemInts(removed), []int{-2, -1}) {
+		t.Error("Diff: should be inverted for swapped versions, got", added, removed)
+	}
+
+	// versions built independently of each other have the same trie
+	other := NewPersistent()
+	for i := 9999; i >= 0; i-- {
+		if i != 3 {
+			other = other.With(i)
+		}
+	}
+	added, removed = base.Diff(other)
+	if len(added) != 0 || !slices.Equal(sortedItemInts(removed), []int{3}) {
+		t.Error("Diff: should work for independent versions, got", added, removed)
+	}
+
+	if added, removed := base.Diff(base); added != nil || removed != nil {
+		t.Error("Diff: should be empty for the same version")
+	}
+}
+
+// TestPersistentSet_collisions builds tries of items whose hashes collide
+// completely, which are kept in linear nodes below the last hash bits.
+func TestPersistentSet_collisions(t *testing.T) {
+	const h = 0xdeadbeef
+	var n *hamtNode = &hamtNode{}
+	for i := 0; i < 4; i++ {
+		var added bool
+		if n, added = n.with(i, h, 0); !added {
+			t.Fatal("with: should add colliding item", i)
+		}
+	}
+	if _, added := n.with(2, h, 0); added {
+		t.Error("with: should not add a colliding item twice")
+	}
+
+	m, _ := n.without(1, h, 0)
+	m, _ = m.without(3, h, 0)
+	if !m.has(0, h, 0) || !m.has(2, h, 0) || m.has(1, h, 0) || !n.has(1, h, 0) {
+		t.Error("without: should remove only the colliding item")
+	}
+
+	var added, removed []interface{}
+	diffTries(n, m, 0,
+		func(item interface{}) { added = append(added, item) },
+		func(item interface{}) { removed = append(removed, item) })
+	if len(added) != 0 || !slices.Equal(sortedItemInts(removed), []int{1, 3}) {
+		t.Error("diffTries: should compare colliding items, got", added, removed)
+	}
+
+	m, _ = m.without(0, h, 0)
+	if len(m.entries) != 1 || m.entries[0].child != nil || m.entries[0].item != 2 {
+		t.Error("without: should replace a subtrie of one item by the item")
+	}
+}
+
+func TestPersistentSet_IsSubset(t *testing.T) {
+	p := NewPersistent(1, 2, 3)
+
+	if !p.IsSubset(New(1, 2)) || p.IsSubset(New(1, 4)) {
+		t.Error("IsSubset: should test whether t is a subset of s")
+	}
+	if !p.IsSuperset(New(1, 2, 3, 4)) || p.IsSuperset(New(1, 2)) {
+		t.Error("IsSuperset: should test whether t is a superset of s")
+	}
+	if !p.Copy().IsEqual(New(1, 2, 3)) {
+		t.Error("Copy: should return a Set with the same items")
+	}
+}
+
+func TestPersistentSet_Race(t *testing.T) {
+	p := NewPersistent()
+	for i := 0; i < 1000; i++ {
+		p = p.With(i)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			q := p
+			for i := 0; i < 1000; i++ {
+				q = q.Without(i).With(g*1000 + i)
+				p.Has(i)
+				p.Diff(q)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if p.Size() != 1000 {
+		t.Error("With: should never change a shared version")
+	}
+}
+
+func BenchmarkPersistentSet_With(b *testing.B) {
+	p := NewPersistent()
+	for i := 0; i < 1<<16; i++ {
+		p = p.With(i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.With(-i)
+	}
+}
+
+func BenchmarkSetNonTS_Copy(b *testing.B) {
+	s := NewNonTS()
+	for i := 0; i < 1<<16; i++ {
+		s.Add(i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Copy().Add(-i)
+	}
+}
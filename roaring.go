@@ -0,0 +1,706 @@
+package set
+
+import (
+	"fmt"
+	"iter"
+	"sort"
+)
+
+// Provides a common baseline for both threadsafe and non-ts roaring sets.
+// Items are split into their high 48 bits, the keys, and their low 16 bits,
+// which are stored in the container of their key. keys is sorted and
+// containers[i] is never empty.
+type roaringSet struct {
+	keys       []uint64
+	containers []container
+	n          int
+}
+
+// RoaringSetNonTS defines a non-thread safe set data structure for sparse
+// unsigned integers, compressed as a Roaring bitmap. Items sharing their high
+// 48 bits are stored together in an array, a bitmap or a run container,
+// whichever is smaller, so even tens of millions of ids take a few bytes
+// each.
+//
+// Only uint64 items can be members, as in other sets uint32(1) and uint64(1)
+// are different items. Add panics for other items, Has reports false for
+// them. Each and List visit the items in
+// ascending order. Union, Intersection, Difference, Merge and Separate work
+// container by container if all sets involved are roaring sets.
+type RoaringSetNonTS struct {
+	roaringSet
+}
+
+// RoaringSet defines a thread safe set data structure for sparse unsigned
+// integers, compressed as a Roaring bitmap. Items sharing their high 48 bits
+// are stored together in an array, a bitmap or a run container, whichever is
+// smaller, so even tens of millions of ids take a few bytes each.
+//
+// Only uint64 items can be members, as in other sets uint32(1) and uint64(1)
+// are different items. Add panics for other items, Has reports false for
+// them. Each and List visit the items in
+// ascending order. Union, Intersection, Difference, Merge and Separate work
+// container by container if all sets involved are roaring sets.
+type RoaringSet struct {
+	roaringSet
+	setLock
+}
+
+// NewRoaringNonTS creates and initialize a new non-threadsafe
+// RoaringSetNonTS. It accepts a variable number of arguments to populate the
+// initial set.
+func NewRoaringNonTS(items ...interface{}) *RoaringSetNonTS {
+	s := &RoaringSetNonTS{}
+
+	// Ensure interface compliance
+	var _ Interface = s
+
+	s.Add(items...)
+	return s
+}
+
+// NewRoaring creates and initialize a new RoaringSet. It accepts a variable
+// number of arguments to populate the initial set.
+func NewRoaring(items ...interface{}) *RoaringSet {
+	s := &RoaringSet{}
+
+	// Ensure interface compliance
+	var _ Interface = s
+
+	s.Add(items...)
+	return s
+}
+
+// roaringItem returns item as a uint64, ok is false if item cannot be a
+// member of a roaring set.
+func roaringItem(item interface{}) (x uint64, ok bool) {
+	x, ok = item.(uint64)
+	return x, ok
+}
+
+// accepts reports whether item can be a member of s.
+func (s *roaringSet) accepts(item interface{}) bool {
+	_, ok := roaringItem(item)
+	return ok
+}
+
+// roaring returns s, it identifies roaring sets for the container-wise
+// operations.
+func (s *roaringSet) roaring() *roaringSet {
+	return s
+}
+
+// search returns the position of key in s.keys, or where it would be.
+func (s *roaringSet) search(key uint64) (int, bool) {
+	i := sort.Search(len(s.keys), func(i int) bool { return s.keys[i] >= key })
+	return i, i < len(s.keys) && s.keys[i] == key
+}
+
+// update replaces the container at i, which held before items, by c and
+// keeps n up to date. A nil c removes the container.
+func (s *roaringSet) update(i int, c container, before int) {
+	if c == nil {
+		s.keys = append(s.keys[:i], s.keys[i+1:]...)
+		s.containers = append(s.containers[:i], s.containers[i+1:]...)
+		s.n -= before
+		return
+	}
+
+	s.containers[i] = c
+	s.n += c.card() - before
+}
+
+func (s *roaringSet) add(x uint64) {
+	i, ok := s.search(x >> 16)
+	if !ok {
+		s.keys = append(s.keys, 0)
+		copy(s.keys[i+1:], s.keys[i:])
+		s.keys[i] = x >> 16
+
+		s.containers = append(s.containers, nil)
+		copy(s.containers[i+1:], s.containers[i:])
+		s.containers[i] = &arrayContainer{values: []uint16{uint16(x)}}
+		s.n++
+		return
+	}
+
+	before := s.containers[i].card()
+	s.update(i, s.containers[i].add(uint16(x)), before)
+}
+
+func (s *roaringSet) remove(x uint64) {
+	if i, ok := s.search(x >> 16); ok {
+		before := s.containers[i].card()
+		s.update(i, s.containers[i].remove(uint16(x)), before)
+	}
+}
+
+func (s *roaringSet) has(x uint64) bool {
+	i, ok := s.search(x >> 16)
+	return ok && s.containers[i].has(uint16(x))
+}
+
+// New creates and initalizes a new RoaringSetNonTS.
+func (s *roaringSet) New(items ...interface{}) Interface {
+	return NewRoaringNonTS(items...)
+}
+
+// Add includes the specified items (one or more) to the set. It panics if
+// one of the items is not a uint64, after adding the items before it.
+func (s *roaringSet) Add(items ...interface{}) {
+	for _, item := range items {
+		x, ok := roaringItem(item)
+		if !ok {
+			panic(fmt.Errorf("set: roaring set item %v of type %T is not a uint64", item, item))
+		}
+		s.add(x)
+	}
+}
+
+// AddUint64 includes the specified items (one or more) to the set.
+func (s *roaringSet) AddUint64(items ...uint64) {
+	for _, x := range items {
+		s.add(x)
+	}
+}
+
+// Remove deletes the specified items from the set.
+func (s *roaringSet) Remove(items ...interface{}) {
+	for _, item := range items {
+		if x, ok := roaringItem(item); ok {
+			s.remove(x)
+		}
+	}
+}
+
+// RemoveUint64 deletes the specified items from the set.
+func (s *roaringSet) RemoveUint64(items ...uint64) {
+	for _, x := range items {
+		s.remove(x)
+	}
+}
+
+// Pop deletes and return the smallest item of the set as a uint64. If set is
+// empty, nil is returned.
+func (s *roaringSet) Pop() interface{} {
+	if s.n == 0 {
+		return nil
+	}
+
+	x := s.keys[0]<<16 | uint64(s.containers[0].first())
+	s.remove(x)
+	return x
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (s *roaringSet) Has(items ...interface{}) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	for _, item := range items {
+		if x, ok := roaringItem(item); !ok || !s.has(x) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasUint64 is Has for uint64 items.
+func (s *roaringSet) HasUint64(items ...uint64) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	for _, x := range items {
+		if !s.has(x) {
+			return false
+		}
+	}
+	return true
+}
+
+// Size returns the number of items in a set.
+func (s *roaringSet) Size() int {
+	return s.n
+}
+
+// Cardinality returns the number of items in a set, it is the same as Size.
+func (s *roaringSet) Cardinality() int {
+	return s.n
+}
+
+// Clear removes all items from the set.
+func (s *roaringSet) Clear() {
+	s.keys = nil
+	s.containers = nil
+	s.n = 0
+}
+
+// IsEmpty reports whether the set is empty.
+func (s *roaringSet) IsEmpty() bool {
+	return s.n == 0
+}
+
+// IsEqual test whether s and t are the same in size and have the same items.
+func (s *roaringSet) IsEqual(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isEqual(s, view(t))
+}
+
+// IsSubset tests whether t is a subset of s.
+func (s *roaringSet) IsSubset(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isSubset(s, view(t))
+}
+
+// IsSuperset tests whether t is a superset of s.
+func (s *roaringSet) IsSuperset(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isSubset(view(t), s)
+}
+
+// eachUint64 calls f for the items of s in ascending order until f returns
+// false.
+func (s *roaringSet) eachUint64(f func(x uint64) bool) {
+	for i, c := range s.containers {
+		high := s.keys[i] << 16
+		if !c.each(func(x uint16) bool { return f(high | uint64(x)) }) {
+			return
+		}
+	}
+}
+
+// Each traverses the items in the set in ascending order, calling the
+// provided function for each set member. Traversal will continue until all
+// items in the set have been visited, or if the closure returns false.
+func (s *roaringSet) Each(f func(item interface{}) bool) {
+	s.eachUint64(func(x uint64) bool { return f(x) })
+}
+
+// All returns an iterator over the items of the set in ascending order.
+func (s *roaringSet) All() iter.Seq[interface{}] {
+	return func(yield func(item interface{}) bool) {
+		s.Each(yield)
+	}
+}
+
+// String returns a string representation of s
+func (s *roaringSet) String() string {
+	return formatItems(s.Uint64s())
+}
+
+// List returns a slice of all items in ascending order.
+func (s *roaringSet) List() []interface{} {
+	list := make([]interface{}, 0, s.n)
+	s.Each(func(item interface{}) bool {
+		list = append(list, item)
+		return true
+	})
+	return list
+}
+
+// Uint64s returns a slice of all items in ascending order.
+func (s *roaringSet) Uint64s() []uint64 {
+	list := make([]uint64, 0, s.n)
+	s.eachUint64(func(x uint64) bool {
+		list = append(list, x)
+		return true
+	})
+	return list
+}
+
+// Copy returns a new RoaringSetNonTS with a copy of s.
+func (s *roaringSet) Copy() Interface {
+	c := NewRoaringNonTS()
+	c.set(s.clone())
+	return c
+}
+
+// RunOptimize converts the containers of s to run containers where that is
+// smaller, which pays off for sets with long runs of consecutive items.
+// Adding or removing an item converts its run container back.
+func (s *roaringSet) RunOptimize() {
+	for i, c := range s.containers {
+		s.containers[i] = runOptimize(c)
+	}
+}
+
+// Merge is like Union, however it modifies the current set it's applied on
+// with the given t set.
+func (s *roaringSet) Merge(t Interface) {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	s.merge(view(t))
+}
+
+func (s *roaringSet) merge(t Interface) {
+	if r, ok := t.(interface{ roaring() *roaringSet }); ok {
+		s.set(unionRoaring(s, r.roaring()))
+		return
+	}
+	s.Add(t.List()...)
+}
+
+// Separate removes the set items containing in t from set s. Please aware that
+// it's not the opposite of Merge.
+func (s *roaringSet) Separate(t Interface) {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	s.separate(view(t))
+}
+
+func (s *roaringSet) separate(t Interface) {
+	if r, ok := t.(interface{ roaring() *roaringSet }); ok {
+		s.set(differenceRoaring(s, r.roaring()))
+		return
+	}
+	s.Remove(t.List()...)
+}
+
+// set replaces the items of s with the ones of r.
+func (s *roaringSet) set(r *roaringSet) {
+	s.keys, s.containers, s.n = r.keys, r.containers, r.n
+}
+
+// clone returns a deep copy of s.
+func (s *roaringSet) clone() *roaringSet {
+	r := &roaringSet{
+		keys:       append([]uint64(nil), s.keys...),
+		containers: make([]container, len(s.containers)),
+		n:          s.n,
+	}
+	for i, c := range s.containers {
+		r.containers[i] = c.clone()
+	}
+	return r
+}
+
+// New creates and initalizes a new RoaringSet.
+func (s *RoaringSet) New(items ...interface{}) Interface {
+	return NewRoaring(items...)
+}
+
+// Add includes the specified items (one or more) to the set. It panics if
+// one of the items is not a uint64, after adding the items before it.
+func (s *RoaringSet) Add(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.roaringSet.Add(items...)
+}
+
+// AddUint64 includes the specified items (one or more) to the set.
+func (s *RoaringSet) AddUint64(items ...uint64) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.roaringSet.AddUint64(items...)
+}
+
+// Remove deletes the specified items from the set.
+func (s *RoaringSet) Remove(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.roaringSet.Remove(items...)
+}
+
+// RemoveUint64 deletes the specified items from the set.
+func (s *RoaringSet) RemoveUint64(items ...uint64) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.roaringSet.RemoveUint64(items...)
+}
+
+// Pop deletes and return the smallest item of the set as a uint64. If set is
+// empty, nil is returned.
+func (s *RoaringSet) Pop() interface{} {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return s.roaringSet.Pop()
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (s *RoaringSet) Has(items ...interface{}) bool {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.roaringSet.Has(items...)
+}
+
+// HasUint64 is Has for uint64 items.
+func (s *RoaringSet) HasUint64(items ...uint64) bool {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.roaringSet.HasUint64(items...)
+}
+
+// Size returns the number of items in a set.
+func (s *RoaringSet) Size() int {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.n
+}
+
+// Cardinality returns the number of items in a set, it is the same as Size.
+func (s *RoaringSet) Cardinality() int {
+	return s.Size()
+}
+
+// Clear removes all items from the set.
+func (s *RoaringSet) Clear() {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.roaringSet.Clear()
+}
+
+// IsEmpty reports whether the set is empty.
+func (s *RoaringSet) IsEmpty() bool {
+	return s.Size() == 0
+}
+
+// IsEqual test whether s and t are the same in size and have the same items.
+func (s *RoaringSet) IsEqual(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isEqual(&s.roaringSet, view(t))
+}
+
+// IsSubset tests whether t is a subset of s.
+func (s *RoaringSet) IsSubset(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isSubset(&s.roaringSet, view(t))
+}
+
+// IsSuperset tests whether t is a superset of s.
+func (s *RoaringSet) IsSuperset(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isSubset(view(t), &s.roaringSet)
+}
+
+// Each traverses the items in the set in ascending order, calling the
+// provided function for each set member. Traversal will continue until all
+// items in the set have been visited, or if the closure returns false.
+func (s *RoaringSet) Each(f func(item interface{}) bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	s.roaringSet.Each(f)
+}
+
+// All returns an iterator over the items of the set in ascending order. As
+// with Each, s is read locked from the start of the loop until it ends, so
+// the loop body must not modify s.
+func (s *RoaringSet) All() iter.Seq[interface{}] {
+	return func(yield func(item interface{}) bool) {
+		s.Each(yield)
+	}
+}
+
+// String returns a string representation of s
+func (s *RoaringSet) String() string {
+	return formatItems(s.Uint64s())
+}
+
+// List returns a slice of all items in ascending order.
+func (s *RoaringSet) List() []interface{} {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.roaringSet.List()
+}
+
+// Uint64s returns a slice of all items in ascending order.
+func (s *RoaringSet) Uint64s() []uint64 {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.roaringSet.Uint64s()
+}
+
+// Copy returns a new RoaringSet with a copy of s.
+func (s *RoaringSet) Copy() Interface {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	c := NewRoaring()
+	c.set(s.clone())
+	return c
+}
+
+// RunOptimize converts the containers of s to run containers where that is
+// smaller, which pays off for sets with long runs of consecutive items.
+// Adding or removing an item converts its run container back.
+func (s *RoaringSet) RunOptimize() {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.roaringSet.RunOptimize()
+}
+
+// Merge is like Union, however it modifies the current set it's applied on
+// with the given t set.
+func (s *RoaringSet) Merge(t Interface) {
+	unlock := lockSets(s, t)
+	defer unlock()
+
+	s.roaringSet.merge(view(t))
+}
+
+// Separate removes the set items containing in t from set s. Please aware that
+// it's not the opposite of Merge.
+func (s *RoaringSet) Separate(t Interface) {
+	unlock := lockSets(s, t)
+	defer unlock()
+
+	s.roaringSet.separate(view(t))
+}
+
+// unlocked returns the underlying roaring set of s, which does not lock.
+func (s *RoaringSet) unlocked() Interface {
+	return &s.roaringSet
+}
+
+// roaringSetsOf returns the roaring sets behind sets, ok is false unless all
+// of them are roaring sets. The sets must be locked.
+func roaringSetsOf(sets []Interface) (rs []*roaringSet, ok bool) {
+	rs = make([]*roaringSet, len(sets))
+	for i, set := range sets {
+		r, ok := view(set).(interface{ roaring() *roaringSet })
+		if !ok {
+			return nil, false
+		}
+		rs[i] = r.roaring()
+	}
+	return rs, true
+}
+
+// newRoaringLike returns set.New() with its items replaced by the ones of r.
+func newRoaringLike(set Interface, r *roaringSet) Interface {
+	n := set.New()
+	view(n).(interface{ roaring() *roaringSet }).roaring().set(r)
+	return n
+}
+
+// unionRoaring returns a new roaring set with the items of a and b.
+func unionRoaring(a, b *roaringSet) *roaringSet {
+	r := &roaringSet{}
+	i, j := 0, 0
+	for i < len(a.keys) || j < len(b.keys) {
+		var key uint64
+		var c container
+
+		switch {
+		case j == len(b.keys) || i < len(a.keys) && a.keys[i] < b.keys[j]:
+			key, c = a.keys[i], a.containers[i].clone()
+			i++
+		case i == len(a.keys) || b.keys[j] < a.keys[i]:
+			key, c = b.keys[j], b.containers[j].clone()
+			j++
+		default:
+			key, c = a.keys[i], unionContainers(a.containers[i], b.containers[j])
+			i++
+			j++
+		}
+
+		r.keys = append(r.keys, key)
+		r.containers = append(r.containers, c)
+		r.n += c.card()
+	}
+	return r
+}
+
+// intersectionRoaring returns a new roaring set with the items of a which
+// are also in b.
+func intersectionRoaring(a, b *roaringSet) *roaringSet {
+	r := &roaringSet{}
+	i, j := 0, 0
+	for i < len(a.keys) && j < len(b.keys) {
+		switch {
+		case a.keys[i] < b.keys[j]:
+			i++
+		case a.keys[i] > b.keys[j]:
+			j++
+		default:
+			if c := intersectContainers(a.containers[i], b.containers[j]); c != nil {
+				r.keys = append(r.keys, a.keys[i])
+				r.containers = append(r.containers, c)
+				r.n += c.card()
+			}
+			i++
+			j++
+		}
+	}
+	return r
+}
+
+// differenceRoaring returns a new roaring set with the items of a which are
+// not in b.
+func differenceRoaring(a, b *roaringSet) *roaringSet {
+	r := &roaringSet{}
+	j := 0
+	for i, key := range a.keys {
+		for j < len(b.keys) && b.keys[j] < key {
+			j++
+		}
+
+		var c container
+		if j < len(b.keys) && b.keys[j] == key {
+			c = differenceContainers(a.containers[i], b.containers[j])
+		} else {
+			c = a.containers[i].clone()
+		}
+		if c != nil {
+			r.keys = append(r.keys, key)
+			r.containers = append(r.containers, c)
+			r.n += c.card()
+		}
+	}
+	return r
+}
+
+// foldRoaring combines rs, at least two, from left to right with op.
+func foldRoaring(rs []*roaringSet, op func(a, b *roaringSet) *roaringSet) *roaringSet {
+	r := rs[0]
+	for _, other := range rs[1:] {
+		r = op(r, other)
+	}
+	return r
+}
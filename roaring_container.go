@@ -0,0 +1,421 @@
+package set
+
+import (
+	"math/bits"
+	"sort"
+)
+
+const (
+	// arrayMax is the largest number of items kept in an array container,
+	// larger containers are bitmaps, which take the same 8 KiB as 4096 uint16.
+	arrayMax = 4096
+
+	// bitmapWords is the number of words of a bitmap container.
+	bitmapWords = 1 << 16 / 64
+)
+
+// container holds the low 16 bits of the items of a RoaringSet which share
+// their high bits. Operations which change a container return the container
+// to use from then on, which may be of another kind; nil means empty.
+type container interface {
+	card() int
+	has(x uint16) bool
+	add(x uint16) container
+	remove(x uint16) container
+	each(f func(x uint16) bool) bool
+	first() uint16
+	clone() container
+}
+
+// arrayContainer is a sorted slice of at most arrayMax items.
+type arrayContainer struct {
+	values []uint16
+}
+
+// bitmapContainer has one bit for each of the 65536 possible items, n is the
+// number of set bits.
+type bitmapContainer struct {
+	words []uint64
+	n     int
+}
+
+// interval is the run of items from start to last, inclusive.
+type interval struct {
+	start, last uint16
+}
+
+// runContainer is a sorted slice of non-adjacent runs. Run containers are
+// only made by RunOptimize and UnmarshalBinary, changing one converts it to
+// an array or bitmap container.
+type runContainer struct {
+	runs []interval
+	n    int
+}
+
+func (c *arrayContainer) card() int {
+	return len(c.values)
+}
+
+func (c *arrayContainer) search(x uint16) (int, bool) {
+	i := sort.Search(len(c.values), func(i int) bool { return c.values[i] >= x })
+	return i, i < len(c.values) && c.values[i] == x
+}
+
+func (c *arrayContainer) has(x uint16) bool {
+	_, ok := c.search(x)
+	return ok
+}
+
+func (c *arrayContainer) add(x uint16) container {
+	i, ok := c.search(x)
+	if ok {
+		return c
+	}
+
+	if len(c.values) == arrayMax {
+		return c.toBitmap().add(x)
+	}
+
+	c.values = append(c.values, 0)
+	copy(c.values[i+1:], c.values[i:])
+	c.values[i] = x
+	return c
+}
+
+func (c *arrayContainer) remove(x uint16) container {
+	i, ok := c.search(x)
+	if !ok {
+		return c
+	}
+
+	c.values = append(c.values[:i], c.values[i+1:]...)
+	if len(c.values) == 0 {
+		return nil
+	}
+	return c
+}
+
+func (c *arrayContainer) each(f func(x uint16) bool) bool {
+	for _, x := range c.values {
+		if !f(x) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *arrayContainer) first() uint16 {
+	return c.values[0]
+}
+
+func (c *arrayContainer) clone() container {
+	return &arrayContainer{values: append([]uint16(nil), c.values...)}
+}
+
+func (c *arrayContainer) toBitmap() *bitmapContainer {
+	b := newBitmapContainer()
+	for _, x := range c.values {
+		b.words[x>>6] |= 1 << (x & 63)
+	}
+	b.n = len(c.values)
+	return b
+}
+
+func newBitmapContainer() *bitmapContainer {
+	return &bitmapContainer{words: make([]uint64, bitmapWords)}
+}
+
+func (c *bitmapContainer) card() int {
+	return c.n
+}
+
+func (c *bitmapContainer) has(x uint16) bool {
+	return c.words[x>>6]&(1<<(x&63)) != 0
+}
+
+func (c *bitmapContainer) add(x uint16) container {
+	if !c.has(x) {
+		c.words[x>>6] |= 1 << (x & 63)
+		c.n++
+	}
+	return c
+}
+
+func (c *bitmapContainer) remove(x uint16) container {
+	if c.has(x) {
+		c.words[x>>6] &^= 1 << (x & 63)
+		c.n--
+	}
+	return c.normalize()
+}
+
+func (c *bitmapContainer) each(f func(x uint16) bool) bool {
+	for w, word := range c.words {
+		for word != 0 {
+			if !f(uint16(w<<6 + bits.TrailingZeros64(word))) {
+				return false
+			}
+			word &= word - 1
+		}
+	}
+	return true
+}
+
+func (c *bitmapContainer) first() uint16 {
+	for w, word := range c.words {
+		if word != 0 {
+			return uint16(w<<6 + bits.TrailingZeros64(word))
+		}
+	}
+	return 0
+}
+
+func (c *bitmapContainer) clone() container {
+	return &bitmapContainer{words: append([]uint64(nil), c.words...), n: c.n}
+}
+
+// setRange sets the bits from start to last, inclusive.
+func (c *bitmapContainer) setRange(start, last uint16) {
+	for x := int(start); x <= int(last); x++ {
+		c.words[x>>6] |= 1 << (x & 63)
+	}
+}
+
+// clearRange clears the bits from start to last, inclusive.
+func (c *bitmapContainer) clearRange(start, last uint16) {
+	for x := int(start); x <= int(last); x++ {
+		c.words[x>>6] &^= 1 << (x & 63)
+	}
+}
+
+// recount updates n after the words were changed directly.
+func (c *bitmapContainer) recount() {
+	c.n = 0
+	for _, w := range c.words {
+		c.n += bits.OnesCount64(w)
+	}
+}
+
+// normalize returns c as an array container if it is small enough, nil if it
+// is empty.
+func (c *bitmapContainer) normalize() container {
+	switch {
+	case c.n == 0:
+		return nil
+	case c.n > arrayMax:
+		return c
+	}
+
+	a := &arrayContainer{values: make([]uint16, 0, c.n)}
+	c.each(func(x uint16) bool {
+		a.values = append(a.values, x)
+		return true
+	})
+	return a
+}
+
+func (c *runContainer) card() int {
+	return c.n
+}
+
+func (c *runContainer) has(x uint16) bool {
+	i := sort.Search(len(c.runs), func(i int) bool { return c.runs[i].last >= x })
+	return i < len(c.runs) && c.runs[i].start <= x
+}
+
+func (c *runContainer) add(x uint16) container {
+	if c.has(x) {
+		return c
+	}
+	return c.unrun().add(x)
+}
+
+func (c *runContainer) remove(x uint16) container {
+	if !c.has(x) {
+		return c
+	}
+	return c.unrun().remove(x)
+}
+
+func (c *runContainer) each(f func(x uint16) bool) bool {
+	for _, r := range c.runs {
+		for x := int(r.start); x <= int(r.last); x++ {
+			if !f(uint16(x)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (c *runContainer) first() uint16 {
+	return c.runs[0].start
+}
+
+func (c *runContainer) clone() container {
+	return &runContainer{runs: append([]interval(nil), c.runs...), n: c.n}
+}
+
+// unrun returns the items of c as an array or bitmap container.
+func (c *runContainer) unrun() container {
+	b := newBitmapContainer()
+	for _, r := range c.runs {
+		b.setRange(r.start, r.last)
+	}
+	b.n = c.n
+	return b.normalize()
+}
+
+// toBitmap returns a new bitmap container with the items of c.
+func toBitmap(c container) *bitmapContainer {
+	switch c := c.(type) {
+	case *arrayContainer:
+		return c.toBitmap()
+	case *bitmapContainer:
+		return c.clone().(*bitmapContainer)
+	case *runContainer:
+		b := newBitmapContainer()
+		for _, r := range c.runs {
+			b.setRange(r.start, r.last)
+		}
+		b.n = c.n
+		return b
+	}
+	panic("set: unknown roaring container")
+}
+
+// filterContainer returns the items of a for which keep returns true.
+func filterContainer(a *arrayContainer, keep func(x uint16) bool) container {
+	values := make([]uint16, 0, len(a.values))
+	for _, x := range a.values {
+		if keep(x) {
+			values = append(values, x)
+		}
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+	return &arrayContainer{values: values}
+}
+
+// unionContainers returns a new container with the items of a and b.
+func unionContainers(a, b container) container {
+	x, okA := a.(*arrayContainer)
+	y, okB := b.(*arrayContainer)
+	if okA && okB && len(x.values)+len(y.values) <= arrayMax {
+		values := make([]uint16, 0, len(x.values)+len(y.values))
+		i, j := 0, 0
+		for i < len(x.values) && j < len(y.values) {
+			switch {
+			case x.values[i] < y.values[j]:
+				values = append(values, x.values[i])
+				i++
+			case x.values[i] > y.values[j]:
+				values = append(values, y.values[j])
+				j++
+			default:
+				values = append(values, x.values[i])
+				i++
+				j++
+			}
+		}
+		values = append(values, x.values[i:]...)
+		values = append(values, y.values[j:]...)
+		return &arrayContainer{values: values}
+	}
+
+	if okB && !okA {
+		a, b = b, a
+	}
+
+	r := toBitmap(a)
+	switch b := b.(type) {
+	case *arrayContainer:
+		for _, x := range b.values {
+			r.words[x>>6] |= 1 << (x & 63)
+		}
+	case *bitmapContainer:
+		for i, w := range b.words {
+			r.words[i] |= w
+		}
+	case *runContainer:
+		for _, run := range b.runs {
+			r.setRange(run.start, run.last)
+		}
+	}
+	r.recount()
+	return r.normalize()
+}
+
+// intersectContainers returns a new container with the items of a which are
+// also in b, or nil if there are none.
+func intersectContainers(a, b container) container {
+	if x, ok := a.(*arrayContainer); ok {
+		return filterContainer(x, b.has)
+	}
+	if y, ok := b.(*arrayContainer); ok {
+		return filterContainer(y, a.has)
+	}
+
+	r := toBitmap(a)
+	other := toBitmap(b)
+	for i := range r.words {
+		r.words[i] &= other.words[i]
+	}
+	r.recount()
+	return r.normalize()
+}
+
+// differenceContainers returns a new container with the items of a which are
+// not in b, or nil if there are none.
+func differenceContainers(a, b container) container {
+	if x, ok := a.(*arrayContainer); ok {
+		return filterContainer(x, func(v uint16) bool { return !b.has(v) })
+	}
+
+	r := toBitmap(a)
+	switch b := b.(type) {
+	case *arrayContainer:
+		for _, x := range b.values {
+			r.words[x>>6] &^= 1 << (x & 63)
+		}
+	case *bitmapContainer:
+		for i, w := range b.words {
+			r.words[i] &^= w
+		}
+	case *runContainer:
+		for _, run := range b.runs {
+			r.clearRange(run.start, run.last)
+		}
+	}
+	r.recount()
+	return r.normalize()
+}
+
+// runOptimize returns c as a run container if that is smaller in the
+// serialized form, otherwise as an array or bitmap container.
+func runOptimize(c container) container {
+	var runs []interval
+	c.each(func(x uint16) bool {
+		if n := len(runs); n > 0 && runs[n-1].last+1 == x {
+			runs[n-1].last = x
+		} else {
+			runs = append(runs, interval{x, x})
+		}
+		return true
+	})
+
+	if r, ok := c.(*runContainer); ok {
+		c = r.unrun()
+	}
+
+	size := 2 * c.card()
+	if _, ok := c.(*bitmapContainer); ok {
+		size = 8 * bitmapWords
+	}
+	if 2+4*len(runs) < size {
+		return &runContainer{runs: runs, n: c.card()}
+	}
+	return c
+}
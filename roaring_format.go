@@ -0,0 +1,331 @@
+package set
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Constants of the Roaring format specification,
+// https://github.com/RoaringBitmap/RoaringFormatSpec.
+const (
+	serialCookieNoRun = 12346
+	serialCookie      = 12347
+	noOffsetThreshold = 4
+)
+
+// errRoaring32Range is returned by MarshalRoaring32 for sets with items which
+// do not fit 32 bits.
+var errRoaring32Range = errors.New("set: roaring set has items above the 32-bit range")
+
+// MarshalBinary implements encoding.BinaryMarshaler. It writes the portable
+// 64-bit Roaring format: the number of 32-bit bitmaps as a little endian
+// uint64, then for every one in ascending order its high 32 bits as a little
+// endian uint32 followed by the 32-bit bitmap in the standard Roaring format.
+// Other Roaring implementations read it as a 64-bit bitmap.
+func (s *roaringSet) MarshalBinary() ([]byte, error) {
+	groups := 0
+	for i, key := range s.keys {
+		if i == 0 || key>>16 != s.keys[i-1]>>16 {
+			groups++
+		}
+	}
+
+	buf := binary.LittleEndian.AppendUint64(nil, uint64(groups))
+	for i := 0; i < len(s.keys); {
+		j := i + 1
+		for j < len(s.keys) && s.keys[j]>>16 == s.keys[i]>>16 {
+			j++
+		}
+
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(s.keys[i]>>16))
+		buf = appendRoaring32(buf, s.keys[i:j], s.containers[i:j])
+		i = j
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It replaces the
+// items of s with the ones of data in the portable 64-bit Roaring format, see
+// MarshalBinary. On error s is unchanged.
+func (s *roaringSet) UnmarshalBinary(data []byte) error {
+	r := &binaryReader{data: data}
+	groups := r.uint64()
+	if r.err != nil {
+		return r.err
+	}
+
+	res := &roaringSet{}
+	for g := uint64(0); g < groups; g++ {
+		high := uint64(r.uint32())
+		if r.err != nil {
+			return r.err
+		}
+		if len(res.keys) > 0 && high<<16 <= res.keys[len(res.keys)-1] {
+			return fmt.Errorf("set: roaring bitmaps are not in ascending order")
+		}
+
+		if err := readRoaring32(r, res, high<<16); err != nil {
+			return err
+		}
+	}
+
+	if len(r.data) != 0 {
+		return fmt.Errorf("set: %d bytes after the roaring bitmap", len(r.data))
+	}
+
+	s.set(res)
+	return nil
+}
+
+// MarshalRoaring32 encodes s in the standard 32-bit Roaring format, which is
+// read by the 32-bit bitmaps of all Roaring implementations. It fails if s has
+// items above the 32-bit range.
+func (s *roaringSet) MarshalRoaring32() ([]byte, error) {
+	if len(s.keys) > 0 && s.keys[len(s.keys)-1] > 0xffff {
+		return nil, errRoaring32Range
+	}
+	return appendRoaring32(nil, s.keys, s.containers), nil
+}
+
+// UnmarshalRoaring32 replaces the items of s with the ones of data in the
+// standard 32-bit Roaring format. On error s is unchanged.
+func (s *roaringSet) UnmarshalRoaring32(data []byte) error {
+	r := &binaryReader{data: data}
+	res := &roaringSet{}
+	if err := readRoaring32(r, res, 0); err != nil {
+		return err
+	}
+
+	if len(r.data) != 0 {
+		return fmt.Errorf("set: %d bytes after the roaring bitmap", len(r.data))
+	}
+
+	s.set(res)
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. It writes the portable
+// 64-bit Roaring format: the number of 32-bit bitmaps as a little endian
+// uint64, then for every one in ascending order its high 32 bits as a little
+// endian uint32 followed by the 32-bit bitmap in the standard Roaring format.
+// Other Roaring implementations read it as a 64-bit bitmap.
+func (s *RoaringSet) MarshalBinary() ([]byte, error) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.roaringSet.MarshalBinary()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It replaces the
+// items of s with the ones of data in the portable 64-bit Roaring format, see
+// MarshalBinary. On error s is unchanged.
+func (s *RoaringSet) UnmarshalBinary(data []byte) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return s.roaringSet.UnmarshalBinary(data)
+}
+
+// MarshalRoaring32 encodes s in the standard 32-bit Roaring format, which is
+// read by the 32-bit bitmaps of all Roaring implementations. It fails if s has
+// items above the 32-bit range.
+func (s *RoaringSet) MarshalRoaring32() ([]byte, error) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.roaringSet.MarshalRoaring32()
+}
+
+// UnmarshalRoaring32 replaces the items of s with the ones of data in the
+// standard 32-bit Roaring format. On error s is unchanged.
+func (s *RoaringSet) UnmarshalRoaring32(data []byte) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return s.roaringSet.UnmarshalRoaring32(data)
+}
+
+// appendRoaring32 appends the containers cs, whose keys share their high 32
+// bits, to buf as one 32-bit bitmap.
+func appendRoaring32(buf []byte, keys []uint64, cs []container) []byte {
+	start := len(buf)
+	size := len(cs)
+
+	var runFlags []byte
+	for i, c := range cs {
+		if _, ok := c.(*runContainer); ok {
+			if runFlags == nil {
+				runFlags = make([]byte, (size+7)/8)
+			}
+			runFlags[i/8] |= 1 << (i % 8)
+		}
+	}
+
+	if runFlags != nil {
+		buf = binary.LittleEndian.AppendUint32(buf, serialCookie|uint32(size-1)<<16)
+		buf = append(buf, runFlags...)
+	} else {
+		buf = binary.LittleEndian.AppendUint32(buf, serialCookieNoRun)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(size))
+	}
+
+	for i, c := range cs {
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(keys[i]))
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(c.card()-1))
+	}
+
+	if runFlags == nil || size >= noOffsetThreshold {
+		offset := len(buf) - start + 4*size
+		for _, c := range cs {
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(offset))
+			offset += containerBytes(c)
+		}
+	}
+
+	for _, c := range cs {
+		switch c := c.(type) {
+		case *arrayContainer:
+			for _, x := range c.values {
+				buf = binary.LittleEndian.AppendUint16(buf, x)
+			}
+		case *bitmapContainer:
+			for _, w := range c.words {
+				buf = binary.LittleEndian.AppendUint64(buf, w)
+			}
+		case *runContainer:
+			buf = binary.LittleEndian.AppendUint16(buf, uint16(len(c.runs)))
+			for _, r := range c.runs {
+				buf = binary.LittleEndian.AppendUint16(buf, r.start)
+				buf = binary.LittleEndian.AppendUint16(buf, r.last-r.start)
+			}
+		}
+	}
+	return buf
+}
+
+// containerBytes returns the size of c in the Roaring format.
+func containerBytes(c container) int {
+	switch c := c.(type) {
+	case *bitmapContainer:
+		return 8 * bitmapWords
+	case *runContainer:
+		return 2 + 4*len(c.runs)
+	}
+	return 2 * c.card()
+}
+
+// readRoaring32 reads one 32-bit bitmap from r and appends its containers to
+// res, with high added to their keys.
+func readRoaring32(r *binaryReader, res *roaringSet, high uint64) error {
+	cookie := r.uint32()
+
+	var size int
+	var runFlags []byte
+	switch {
+	case r.err != nil:
+		return r.err
+	case cookie&0xffff == serialCookie:
+		size = int(cookie>>16) + 1
+		runFlags = r.next((size + 7) / 8)
+	case cookie == serialCookieNoRun:
+		size = int(r.uint32())
+	default:
+		return fmt.Errorf("set: unknown roaring cookie %d", cookie)
+	}
+
+	// every container takes at least 4 header bytes, don't trust size any
+	// further
+	if r.err != nil || size > len(r.data)/4 {
+		return errBinaryTruncated
+	}
+
+	keys := make([]uint16, size)
+	cards := make([]int, size)
+	for i := range keys {
+		keys[i] = r.uint16()
+		cards[i] = int(r.uint16()) + 1
+		if i > 0 && keys[i] <= keys[i-1] {
+			return fmt.Errorf("set: roaring container keys are not in ascending order")
+		}
+	}
+
+	if runFlags == nil || size >= noOffsetThreshold {
+		r.next(4 * size)
+	}
+
+	for i, key := range keys {
+		var c container
+		var err error
+
+		switch {
+		case runFlags != nil && runFlags[i/8]&(1<<(i%8)) != 0:
+			c, err = readRunContainer(r)
+		case cards[i] <= arrayMax:
+			c, err = readArrayContainer(r, cards[i])
+		default:
+			c, err = readBitmapContainer(r)
+		}
+
+		if err == nil && r.err != nil {
+			err = r.err
+		}
+		if err != nil {
+			return err
+		}
+		if c.card() != cards[i] {
+			return fmt.Errorf("set: roaring container has %d items, header says %d", c.card(), cards[i])
+		}
+
+		res.keys = append(res.keys, high|uint64(key))
+		res.containers = append(res.containers, c)
+		res.n += c.card()
+	}
+	return nil
+}
+
+func readArrayContainer(r *binaryReader, card int) (container, error) {
+	c := &arrayContainer{values: make([]uint16, card)}
+	for i := range c.values {
+		c.values[i] = r.uint16()
+		if i > 0 && c.values[i] <= c.values[i-1] && r.err == nil {
+			return nil, fmt.Errorf("set: roaring array container is not sorted")
+		}
+	}
+	return c, r.err
+}
+
+func readBitmapContainer(r *binaryReader) (container, error) {
+	c := newBitmapContainer()
+	for i := range c.words {
+		c.words[i] = r.uint64()
+	}
+	c.recount()
+	return c, r.err
+}
+
+func readRunContainer(r *binaryReader) (container, error) {
+	n := int(r.uint16())
+	if r.err != nil || n > len(r.data)/4 {
+		return nil, errBinaryTruncated
+	}
+
+	c := &runContainer{runs: make([]interval, n)}
+	for i := range c.runs {
+		start, length := r.uint16(), r.uint16()
+		if int(start)+int(length) > 0xffff {
+			return nil, fmt.Errorf("set: roaring run exceeds its container")
+		}
+		if i > 0 && start <= c.runs[i-1].last {
+			return nil, fmt.Errorf("set: roaring runs are not sorted")
+		}
+
+		c.runs[i] = interval{start, start + length}
+		c.n += int(length) + 1
+	}
+
+	if n == 0 {
+		return nil, fmt.Errorf("set: empty roaring run container")
+	}
+	return c, r.err
+}
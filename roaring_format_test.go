@@ -0,0 +1,114 @@
+package set
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRoaringSet_MarshalRoaring32(t *testing.T) {
+	// {1, 2, 3, 131077} in the Roaring format without run containers
+	want := []byte{
+		0x3a, 0x30, 0, 0, 2, 0, 0, 0, // cookie, two containers
+		0, 0, 2, 0, 2, 0, 0, 0, // keys and cardinalities - 1
+		24, 0, 0, 0, 30, 0, 0, 0, // offsets
+		1, 0, 2, 0, 3, 0, // array container 0
+		5, 0, // array container 2
+	}
+
+	s := NewRoaring(uint64(1), uint64(2), uint64(3), uint64(2<<16|5))
+	if data, err := s.MarshalRoaring32(); err != nil || !bytes.Equal(data, want) {
+		t.Errorf("MarshalRoaring32: got %v, %v want %v", data, err, want)
+	}
+
+	u := NewRoaringNonTS(uint64(9))
+	if err := u.UnmarshalRoaring32(want); err != nil || !u.IsEqual(s) {
+		t.Error("UnmarshalRoaring32: got", u, err)
+	}
+
+	if _, err := NewRoaring(uint64(1) << 32).MarshalRoaring32(); err == nil {
+		t.Error("MarshalRoaring32: should fail for items above 32 bits")
+	}
+}
+
+func TestRoaringSet_MarshalRoaring32_Runs(t *testing.T) {
+	// {0, ..., 99} as one run container
+	want := []byte{
+		0x3b, 0x30, 0, 0, // cookie with runs, one container
+		1,           // run flags
+		0, 0, 99, 0, // key and cardinality - 1, no offsets below 4 containers
+		1, 0, 0, 0, 99, 0, // one run of 100 items
+	}
+
+	s := NewRoaring()
+	for i := uint64(0); i < 100; i++ {
+		s.AddUint64(i)
+	}
+	s.RunOptimize()
+
+	if data, err := s.MarshalRoaring32(); err != nil || !bytes.Equal(data, want) {
+		t.Errorf("MarshalRoaring32: got %v, %v want %v", data, err, want)
+	}
+
+	u := NewRoaring()
+	if err := u.UnmarshalRoaring32(want); err != nil || !u.IsEqual(s) || !u.Has(uint64(99)) || u.Has(uint64(100)) {
+		t.Error("UnmarshalRoaring32: got", u, err)
+	}
+}
+
+func TestRoaringSet_MarshalBinary(t *testing.T) {
+	s := NewRoaring(uint64(1), uint64(1)<<40|3, uint64(1)<<40|1<<20)
+	for i := uint64(0); i < 5000; i++ {
+		s.AddUint64(i * 3)
+	}
+	for i := uint64(1 << 33); i < 1<<33+70000; i++ {
+		s.AddUint64(i)
+	}
+	s.RunOptimize()
+
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// three 32-bit bitmaps, for the high bits 0, 2 and 256
+	if !bytes.Equal(data[:12], []byte{3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}) {
+		t.Error("MarshalBinary: wrong header", data[:12])
+	}
+
+	u := NewRoaringNonTS()
+	if err := u.UnmarshalBinary(data); err != nil || !u.IsEqual(s) || u.Size() != s.Size() {
+		t.Error("UnmarshalBinary: got", u.Size(), err)
+	}
+
+	for n := 0; n < len(data); n += 997 {
+		if err := u.UnmarshalBinary(data[:n]); err == nil {
+			t.Errorf("UnmarshalBinary: should fail for %d of %d bytes", n, len(data))
+		}
+	}
+	if !u.IsEqual(s) {
+		t.Error("UnmarshalBinary: should leave the set unchanged on error")
+	}
+}
+
+func FuzzRoaringSet_UnmarshalBinary(f *testing.F) {
+	s := NewRoaring(uint64(5), uint64(1)<<40)
+	data, _ := s.MarshalBinary()
+	f.Add(data)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		s := NewRoaringNonTS()
+		if err := s.UnmarshalBinary(data); err != nil {
+			return
+		}
+
+		again, err := s.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u := NewRoaringNonTS()
+		if err := u.UnmarshalBinary(again); err != nil || !u.IsEqual(s) {
+			t.Fatal("UnmarshalBinary: round trip failed", err)
+		}
+	})
+}
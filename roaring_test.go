@@ -0,0 +1,258 @@
+package set
+
+import (
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestRoaringSet_Add(t *testing.T) {
+	for _, s := range []Interface{NewRoaring(), NewRoaringNonTS()} {
+		s.Add(uint64(1)<<40, uint64(7), uint64(7), uint64(70000))
+
+		if s.Size() != 3 || !s.Has(uint64(7), uint64(70000), uint64(1)<<40) || s.Has(7) || s.Has(uint32(7)) || s.Has(uint64(8)) {
+			t.Error("Add: wrong items", s)
+		}
+
+		if !reflect.DeepEqual(s.List(), []interface{}{uint64(7), uint64(70000), uint64(1) << 40}) {
+			t.Error("List: should return uint64 items in ascending order, got", s.List())
+		}
+
+		s.Remove(uint32(7), 7, "x")
+		if s.Size() != 3 {
+			t.Error("Remove: should ignore items which are not uint64", s)
+		}
+
+		s.Remove(uint64(70000))
+		if s.Size() != 2 || s.Has(uint64(70000)) {
+			t.Error("Remove: wrong items", s)
+		}
+
+		if item := s.Pop(); item != uint64(7) || s.Size() != 1 {
+			t.Error("Pop: should remove the smallest item, got", item)
+		}
+	}
+
+	for _, item := range []interface{}{1, uint32(1)} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Add: should panic for %v of type %T", item, item)
+				}
+			}()
+			NewRoaring(item)
+		}()
+	}
+}
+
+func TestRoaringSet_Mixed(t *testing.T) {
+	r, m := NewRoaring(uint64(1), uint64(2)), New(uint32(1), uint64(2))
+
+	if !Intersection(r, m).IsEqual(Intersection(m, r)) || !Intersection(r, m).IsEqual(New(uint64(2))) {
+		t.Error("Intersection: should not depend on the order of the sets, got", Intersection(r, m), Intersection(m, r))
+	}
+	if r.IsEqual(m) || m.IsEqual(r) {
+		t.Error("IsEqual: uint32 and uint64 items should differ")
+	}
+
+	u := Union(r, m)
+	if _, ok := u.(*SetNonTS); !ok || !u.IsEqual(New(uint64(1), uint64(2), uint32(1))) {
+		t.Errorf("Union: should return a plain set with both kinds of items, got %T %v", u, u)
+	}
+}
+
+// TestRoaringSet_Containers moves one container through all its kinds and
+// compares it to a map based set after every step.
+func TestRoaringSet_Containers(t *testing.T) {
+	r := NewRoaringNonTS()
+	m := NewNonTS()
+	rnd := rand.New(rand.NewSource(1))
+
+	check := func(step string) {
+		t.Helper()
+		if r.Size() != m.Size() || !r.IsEqual(m) || !m.IsEqual(NewNonTS(r.List()...)) {
+			t.Fatalf("%s: roaring set has %d items, want %d", step, r.Size(), m.Size())
+		}
+	}
+
+	for i := 0; i < 10000; i++ {
+		x := uint64(rnd.Intn(1 << 17))
+		r.AddUint64(x)
+		m.Add(x)
+	}
+	check("Add")
+
+	for i := uint64(20000); i < 30000; i++ {
+		r.AddUint64(i)
+		m.Add(i)
+	}
+	r.RunOptimize()
+	check("RunOptimize")
+
+	for i := 0; i < 15000; i++ {
+		x := uint64(rnd.Intn(1 << 17))
+		r.RemoveUint64(x)
+		m.Remove(x)
+	}
+	check("Remove")
+
+	for !r.IsEmpty() {
+		m.Remove(r.Pop())
+	}
+	check("Pop")
+}
+
+func TestRoaringSet_Operations(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	sets := make([]*RoaringSet, 3)
+	maps := make([]*Set, 3)
+	for i := range sets {
+		sets[i], maps[i] = NewRoaring(), New()
+		for j := 0; j < 20000; j++ {
+			// dense at the start, sparse above
+			x := uint64(rnd.Intn(1 << 14))
+			if j%2 == 0 {
+				x = uint64(rnd.Int63n(1 << 34))
+			}
+			sets[i].AddUint64(x)
+			maps[i].Add(x)
+		}
+		if i == 2 {
+			sets[i].AddUint64(1, 2, 3, 4, 5, 6)
+			maps[i].Add(uint64(1), uint64(2), uint64(3), uint64(4), uint64(5), uint64(6))
+			sets[i].RunOptimize()
+		}
+	}
+
+	for name, test := range map[string]struct{ got, want Interface }{
+		"Union":        {Union(sets[0], sets[1], sets[2]), Union(maps[0], maps[1], maps[2])},
+		"Intersection": {Intersection(sets[0], sets[1]), Intersection(maps[0], maps[1])},
+		"Difference":   {Difference(sets[0], sets[1], sets[2]), Difference(maps[0], maps[1], maps[2])},
+	} {
+		if _, ok := test.got.(*RoaringSet); !ok {
+			t.Errorf("%s: should return a *RoaringSet, got %T", name, test.got)
+		}
+		if !test.got.IsEqual(test.want) || test.got.Size() != test.want.Size() {
+			t.Errorf("%s: has %d items, want %d", name, test.got.Size(), test.want.Size())
+		}
+	}
+
+	c := sets[0].Copy()
+	c.Merge(sets[1])
+	c.Separate(sets[2])
+	if !c.IsEqual(Difference(Union(maps[0], maps[1]), maps[2])) {
+		t.Error("Merge: wrong items after Merge and Separate")
+	}
+
+	c.Merge(New(uint64(1) << 50))
+	if !c.Has(uint64(1)<<50) || !c.IsSubset(New(uint64(1)<<50)) {
+		t.Error("Merge: should accept other sets")
+	}
+}
+
+func TestRoaringSet_Race(t *testing.T) {
+	s := NewRoaring()
+	u := NewRoaring()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				s.AddUint64(uint64(g*200 + i))
+				u.Merge(s)
+				Intersection(s, u)
+				s.MarshalBinary()
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if s.Size() != 1600 || !u.IsEqual(s) {
+		t.Error("Race: wrong size", s.Size())
+	}
+}
+
+// sparseIDs returns n distinct sparse uint32 ids as uint64.
+func sparseIDs(n int) []uint64 {
+	rnd := rand.New(rand.NewSource(3))
+	ids := make([]uint64, n)
+	for i := range ids {
+		ids[i] = uint64(rnd.Uint32())
+	}
+	return ids
+}
+
+func BenchmarkRoaringSet_Build(b *testing.B) {
+	ids := sparseIDs(1 << 20)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s := NewRoaringNonTS()
+		s.AddUint64(ids...)
+	}
+}
+
+func BenchmarkSetNonTS_Build(b *testing.B) {
+	ids := sparseIDs(1 << 20)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s := NewNonTS()
+		for _, id := range ids {
+			s.Add(id)
+		}
+	}
+}
+
+func BenchmarkRoaringSet_Has(b *testing.B) {
+	ids := sparseIDs(1 << 20)
+	s := NewRoaringNonTS()
+	s.AddUint64(ids...)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.HasUint64(ids[i&(len(ids)-1)])
+	}
+}
+
+func BenchmarkSetNonTS_Has(b *testing.B) {
+	ids := sparseIDs(1 << 20)
+	s := NewNonTS()
+	for _, id := range ids {
+		s.Add(id)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Has(ids[i&(len(ids)-1)])
+	}
+}
+
+func BenchmarkRoaringSet_Intersection(b *testing.B) {
+	ids := sparseIDs(1 << 20)
+	x, y := NewRoaringNonTS(), NewRoaringNonTS()
+	x.AddUint64(ids[:1<<19]...)
+	y.AddUint64(ids[1<<18:]...)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Intersection(x, y)
+	}
+}
+
+func BenchmarkSetNonTS_Intersection(b *testing.B) {
+	ids := sparseIDs(1 << 20)
+	x, y := NewNonTS(), NewNonTS()
+	for _, id := range ids[:1<<19] {
+		x.Add(id)
+	}
+	for _, id := range ids[1<<18:] {
+		y.Add(id)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Intersection(x, y)
+	}
+}
@@ -2,9 +2,17 @@
 // a generic set data structure. In the threadsafe set, safety encompasses all
 // operations on one set. Operations on multiple sets are consistent in that
 // the elements of each set used was valid at exactly one point in time
-// between the start and the end of the operation.
+// between the start and the end of the operation. To keep that promise without
+// deadlocks, every operation that involves several threadsafe sets locks them
+// in one global order.
+//
+// Next to the untyped Interface, Set and SetNonTS the package provides the
+// type-parameterized InterfaceOf, SetOf and SetNonTSOf, which store items as
+// their own type instead of interface{}.
 package set
 
+import "iter"
+
 // Interface is describing a Set. Sets are an unordered, unique list of values.
 type Interface interface {
 	New(items ...interface{}) Interface
@@ -19,6 +27,7 @@ type Interface interface {
 	IsSubset(s Interface) bool
 	IsSuperset(s Interface) bool
 	Each(func(interface{}) bool)
+	All() iter.Seq[interface{}]
 	String() string
 	List() []interface{}
 	Copy() Interface
@@ -33,61 +42,134 @@ var keyExists = struct{}{}
 // elements present in all the sets that are passed.
 //
 // The dynamic type of the returned set is determined by the first passed set's
-// implementation of the New() method.
+// implementation of the New() method. If the first set only holds some items,
+// like a BitSet, and the other sets have items it cannot hold, a SetNonTS is
+// returned instead.
 func Union(set1, set2 Interface, sets ...Interface) Interface {
-	u := set1.Copy()
-	set2.Each(func(item interface{}) bool {
-		u.Add(item)
-		return true
-	})
-	for _, set := range sets {
-		set.Each(func(item interface{}) bool {
-			u.Add(item)
-			return true
-		})
+	all := append([]Interface{set1, set2}, sets...)
+
+	unlock := lockInterfaces(all)
+	defer unlock()
+
+	if bs, ok := bitSetsOf(all); ok {
+		return newBitsLike(set1, unionWords(bs))
+	}
+	if rs, ok := roaringSetsOf(all); ok {
+		return newRoaringLike(set1, foldRoaring(rs, unionRoaring))
 	}
 
-	return u
+	items := make([]interface{}, 0, view(set1).Size())
+	for _, set := range all {
+		items = append(items, view(set).List()...)
+	}
+
+	return newLike(set1, items)
 }
 
 // Difference returns a new set which contains items which are in in the first
 // set but not in the others. Unlike the Difference() method you can use this
 // function separately with multiple sets.
 func Difference(set1, set2 Interface, sets ...Interface) Interface {
-	s := set1.Copy()
-	s.Separate(set2)
-	for _, set := range sets {
-		s.Separate(set) // seperate is thread safe
+	all := append([]Interface{set1, set2}, sets...)
+	others := all[1:]
+
+	unlock := lockInterfaces(all)
+	defer unlock()
+
+	if bs, ok := bitSetsOf(all); ok {
+		return newBitsLike(set1, differenceWords(bs))
+	}
+	if rs, ok := roaringSetsOf(all); ok {
+		return newRoaringLike(set1, foldRoaring(rs, differenceRoaring))
 	}
-	return s
+
+	return set1.New(filter(view(set1), func(item interface{}) bool {
+		for _, set := range others {
+			if view(set).Has(item) {
+				return false
+			}
+		}
+		return true
+	})...)
 }
 
 // Intersection returns a new set which contains items that only exist in all given sets.
 func Intersection(set1, set2 Interface, sets ...Interface) Interface {
-	all := Union(set1, set2, sets...)
-	result := Union(set1, set2, sets...)
+	all := append([]Interface{set1, set2}, sets...)
+	others := all[1:]
 
-	all.Each(func(item interface{}) bool {
-		if !set1.Has(item) || !set2.Has(item) {
-			result.Remove(item)
-		}
+	unlock := lockInterfaces(all)
+	defer unlock()
+
+	if bs, ok := bitSetsOf(all); ok {
+		return newBitsLike(set1, intersectionWords(bs))
+	}
+	if rs, ok := roaringSetsOf(all); ok {
+		return newRoaringLike(set1, foldRoaring(rs, intersectionRoaring))
+	}
 
-		for _, set := range sets {
-			if !set.Has(item) {
-				result.Remove(item)
+	return set1.New(filter(view(set1), func(item interface{}) bool {
+		for _, set := range others {
+			if !view(set).Has(item) {
+				return false
 			}
 		}
 		return true
-	})
-	return result
+	})...)
 }
 
 // SymmetricDifference returns a new set which s is the difference of items which are in
-// one of either, but not in both.
+// one of either, but not in both. As with Union, a SetNonTS is returned if s
+// cannot hold the items of t.
 func SymmetricDifference(s Interface, t Interface) Interface {
-	u := Difference(s, t)
-	v := Difference(t, s)
-	return Union(u, v)
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	u, v := view(s), view(t)
+	items := filter(u, func(item interface{}) bool { return !v.Has(item) })
+	items = append(items, filter(v, func(item interface{}) bool { return !u.Has(item) })...)
+
+	return newLike(s, items)
+}
+
+// newLike returns set.New(items...), or a SetNonTS with items if set only
+// holds some items and not all of items. set must be locked.
+func newLike(set Interface, items []interface{}) Interface {
+	if a, ok := view(set).(interface{ accepts(item interface{}) bool }); ok {
+		for _, item := range items {
+			if !a.accepts(item) {
+				return NewNonTS(items...)
+			}
+		}
+	}
+	return set.New(items...)
+}
+
+// filter returns the items of s for which keep returns true.
+func filter(s Interface, keep func(item interface{}) bool) []interface{} {
+	var items []interface{}
+	s.Each(func(item interface{}) bool {
+		if keep(item) {
+			items = append(items, item)
+		}
+		return true
+	})
+	return items
+}
+
+// isSubset tests whether t is a subset of s. Neither set is locked.
+func isSubset(s, t Interface) bool {
+	subset := true
+	t.Each(func(item interface{}) bool {
+		subset = s.Has(item)
+		return subset
+	})
+	return subset
+}
+
+// isEqual tests whether s and t have the same items. Neither set is locked.
+func isEqual(s, t Interface) bool {
+	return s.Size() == t.Size() && isSubset(s, t)
 }
 
 // StringSlice is a helper function that returns a slice of strings of s. If
@@ -107,7 +189,12 @@ func StringSlice(s Interface) []string {
 
 // IntSlice is a helper function that returns a slice of ints of s. If
 // the set contains mixed types of items only items of type int are returned.
+// For bit sets the items are returned in ascending order.
 func IntSlice(s Interface) []int {
+	if b, ok := s.(interface{ Ints() []int }); ok {
+		return b.Ints()
+	}
+
 	slice := make([]int, 0)
 	for _, item := range s.List() {
 		v, ok := item.(int)
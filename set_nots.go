@@ -2,12 +2,14 @@ package set
 
 import (
 	"fmt"
+	"iter"
 	"strings"
 )
 
 // Provides a common set baseline for both threadsafe and non-ts Sets.
 type set struct {
-	m map[interface{}]struct{} // struct{} doesn't take up space
+	m        map[interface{}]struct{} // struct{} doesn't take up space
+	validate bool                     // check items in Add, see ValidateItems
 }
 
 // SetNonTS defines a non-thread safe set data structure.
@@ -33,21 +35,42 @@ func NewNonTS(items ...interface{}) *SetNonTS {
 // number of arguments to populate the initial set. If nothing is passed a
 // zero size Set based on the struct is created.
 func (s *set) New(items ...interface{}) Interface {
-	return NewNonTS(items...)
+	n := NewNonTS()
+	n.validate = s.validate
+	n.Add(items...)
+	return n
 }
 
 // Add includes the specified items (one or more) to the set. The underlying
-// Set s is modified. If passed nothing it silently returns.
+// Set s is modified. If passed nothing it silently returns. See TryAdd and
+// ValidateItems for batches which may contain unhashable items.
 func (s *set) Add(items ...interface{}) {
 	if len(items) == 0 {
 		return
 	}
 
+	s.checkItems(items)
+	s.add(items)
+}
+
+func (s *set) add(items []interface{}) {
 	for _, item := range items {
 		s.m[item] = keyExists
 	}
 }
 
+// checkItems panics with an *UnhashableError if s validates its items and
+// one of items is unhashable.
+func (s *set) checkItems(items []interface{}) {
+	if !s.validate {
+		return
+	}
+
+	if err := checkHashable(items); err != nil {
+		panic(err)
+	}
+}
+
 // Remove deletes the specified items from the set.  The underlying Set s is
 // modified. If passed nothing it silently returns.
 func (s *set) Remove(items ...interface{}) {
@@ -60,7 +83,7 @@ func (s *set) Remove(items ...interface{}) {
 	}
 }
 
-// Pop  deletes and return an item from the set. The underlying Set s is
+// Pop deletes and return an item from the set. The underlying Set s is
 // modified. If set is empty, nil is returned.
 func (s *set) Pop() interface{} {
 	for item := range s.m {
@@ -70,6 +93,39 @@ func (s *set) Pop() interface{} {
 	return nil
 }
 
+// PopN deletes and returns up to n items from the set. Fewer items are
+// returned if the set holds less than n of them.
+func (s *set) PopN(n int) []interface{} {
+	if n <= 0 {
+		return nil
+	}
+	if n > len(s.m) {
+		n = len(s.m)
+	}
+
+	items := make([]interface{}, 0, n)
+	for item := range s.m {
+		if len(items) == n {
+			break
+		}
+		delete(s.m, item)
+		items = append(items, item)
+	}
+	return items
+}
+
+// PopIf deletes and returns all items for which pred returns true.
+func (s *set) PopIf(pred func(item interface{}) bool) []interface{} {
+	var items []interface{}
+	for item := range s.m {
+		if pred(item) {
+			delete(s.m, item)
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // Has looks for the existence of items passed. It returns false if nothing is
 // passed. For multiple items it returns true only if all of  the items exist.
 func (s *set) Has(items ...interface{}) bool {
@@ -92,6 +148,12 @@ func (s *set) Size() int {
 	return len(s.m)
 }
 
+// items returns the map of s, it identifies map based sets for operations
+// which read the maps of two sets directly.
+func (s *set) items() map[interface{}]struct{} {
+	return s.m
+}
+
 // Clear removes all items from the set.
 func (s *set) Clear() {
 	s.m = make(map[interface{}]struct{})
@@ -104,41 +166,26 @@ func (s *set) IsEmpty() bool {
 
 // IsEqual test whether s and t are the same in size and have the same items.
 func (s *set) IsEqual(t Interface) bool {
-	// Force locking only if given set is threadsafe.
-	if conv, ok := t.(*Set); ok {
-		conv.l.RLock()
-		defer conv.l.RUnlock()
-	}
-
-	// return false if they are no the same size
-	if sameSize := len(s.m) == t.Size(); !sameSize {
-		return false
-	}
-
-	equal := true
-	t.Each(func(item interface{}) bool {
-		_, equal = s.m[item]
-		return equal // if false, Each() will end
-	})
+	unlock := lockSets(nil, t)
+	defer unlock()
 
-	return equal
+	return isEqual(s, view(t))
 }
 
 // IsSubset tests whether t is a subset of s.
-func (s *set) IsSubset(t Interface) (subset bool) {
-	subset = true
+func (s *set) IsSubset(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
 
-	t.Each(func(item interface{}) bool {
-		_, subset = s.m[item]
-		return subset
-	})
-
-	return
+	return isSubset(s, view(t))
 }
 
 // IsSuperset tests whether t is a superset of s.
 func (s *set) IsSuperset(t Interface) bool {
-	return t.IsSubset(s)
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isSubset(view(t), s)
 }
 
 // Each traverses the items in the Set, calling the provided function for each
@@ -152,6 +199,14 @@ func (s *set) Each(f func(item interface{}) bool) {
 	}
 }
 
+// All returns an iterator over the items of the set, visiting them in the
+// same order as Each.
+func (s *set) All() iter.Seq[interface{}] {
+	return func(yield func(item interface{}) bool) {
+		s.Each(yield)
+	}
+}
+
 // String returns a string representation of s
 func (s *set) String() string {
 	t := make([]string, 0, len(s.List()))
@@ -176,20 +231,26 @@ func (s *set) List() []interface{} {
 
 // Copy returns a new Set with a copy of s.
 func (s *set) Copy() Interface {
-	return NewNonTS(s.List()...)
+	return s.New(s.List()...)
 }
 
 // Merge is like Union, however it modifies the current set it's applied on
 // with the given t set.
 func (s *set) Merge(t Interface) {
-	t.Each(func(item interface{}) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	view(t).Each(func(item interface{}) bool {
 		s.m[item] = keyExists
 		return true
 	})
 }
 
-// it's not the opposite of Merge.
 // Separate removes the set items containing in t from set s. Please aware that
+// it's not the opposite of Merge.
 func (s *set) Separate(t Interface) {
-	s.Remove(t.List()...)
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	s.Remove(view(t).List()...)
 }
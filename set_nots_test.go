@@ -109,6 +109,20 @@ func TestSetNonTS_Pop(t *testing.T) {
 	s.Pop() // try to remove something from a zero length set
 }
 
+func TestSetNonTS_PopN(t *testing.T) {
+	s := NewNonTS("a", "b", "c")
+
+	items := s.PopN(2)
+	if len(items) != 2 || s.Size() != 1 {
+		t.Error("PopN: should return two items and leave one in the set")
+	}
+
+	rest := s.PopIf(func(item interface{}) bool { return true })
+	if len(rest) != 1 || !s.IsEmpty() {
+		t.Error("PopIf: should remove the remaining item")
+	}
+}
+
 func TestSetNonTS_Has(t *testing.T) {
 	s := NewNonTS("1", "2", "3", "4")
 
@@ -0,0 +1,144 @@
+package set
+
+import (
+	"fmt"
+	"iter"
+)
+
+// InterfaceOf is the type-parameterized counterpart of Interface. Items are
+// stored and returned as T, so no boxing into interface{} and no type
+// assertions are needed.
+//
+// The untyped Interface, Set and SetNonTS remain as a compatibility layer;
+// the generic types carry an "Of" suffix because Go does not allow a generic
+// and a non-generic type to share a name.
+type InterfaceOf[T comparable] interface {
+	New(items ...T) InterfaceOf[T]
+	Add(items ...T)
+	Remove(items ...T)
+	Pop() (T, bool)
+	Has(items ...T) bool
+	Size() int
+	Clear()
+	IsEmpty() bool
+	IsEqual(s InterfaceOf[T]) bool
+	IsSubset(s InterfaceOf[T]) bool
+	IsSuperset(s InterfaceOf[T]) bool
+	Each(func(T) bool)
+	All() iter.Seq[T]
+	String() string
+	List() []T
+	Copy() InterfaceOf[T]
+	Merge(s InterfaceOf[T])
+	Separate(s InterfaceOf[T])
+}
+
+// UnionOf is the merger of multiple sets. It returns a new set with all the
+// elements present in all the sets that are passed.
+//
+// The dynamic type of the returned set is determined by the first passed set's
+// implementation of the New() method.
+func UnionOf[T comparable](set1, set2 InterfaceOf[T], sets ...InterfaceOf[T]) InterfaceOf[T] {
+	all := append([]InterfaceOf[T]{set1, set2}, sets...)
+
+	unlock := lockInterfacesOf(all)
+	defer unlock()
+
+	items := make([]T, 0, viewOf(set1).Size())
+	for _, set := range all {
+		items = append(items, viewOf(set).List()...)
+	}
+
+	return set1.New(items...)
+}
+
+// DifferenceOf returns a new set which contains items which are in the first
+// set but not in the others.
+func DifferenceOf[T comparable](set1, set2 InterfaceOf[T], sets ...InterfaceOf[T]) InterfaceOf[T] {
+	others := append([]InterfaceOf[T]{set2}, sets...)
+
+	unlock := lockInterfacesOf(append(others, set1))
+	defer unlock()
+
+	return set1.New(filterOf(viewOf(set1), func(item T) bool {
+		for _, set := range others {
+			if viewOf(set).Has(item) {
+				return false
+			}
+		}
+		return true
+	})...)
+}
+
+// IntersectionOf returns a new set which contains items that only exist in all
+// given sets.
+func IntersectionOf[T comparable](set1, set2 InterfaceOf[T], sets ...InterfaceOf[T]) InterfaceOf[T] {
+	others := append([]InterfaceOf[T]{set2}, sets...)
+
+	unlock := lockInterfacesOf(append(others, set1))
+	defer unlock()
+
+	return set1.New(filterOf(viewOf(set1), func(item T) bool {
+		for _, set := range others {
+			if !viewOf(set).Has(item) {
+				return false
+			}
+		}
+		return true
+	})...)
+}
+
+// SymmetricDifferenceOf returns a new set which s is the difference of items
+// which are in one of either, but not in both.
+func SymmetricDifferenceOf[T comparable](s InterfaceOf[T], t InterfaceOf[T]) InterfaceOf[T] {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	u, v := viewOf(s), viewOf(t)
+	items := filterOf(u, func(item T) bool { return !v.Has(item) })
+	items = append(items, filterOf(v, func(item T) bool { return !u.Has(item) })...)
+
+	return s.New(items...)
+}
+
+// filterOf returns the items of s for which keep returns true.
+func filterOf[T comparable](s InterfaceOf[T], keep func(item T) bool) []T {
+	var items []T
+	s.Each(func(item T) bool {
+		if keep(item) {
+			items = append(items, item)
+		}
+		return true
+	})
+	return items
+}
+
+// isSubsetOf tests whether t is a subset of s. Neither set is locked.
+func isSubsetOf[T comparable](s, t InterfaceOf[T]) bool {
+	subset := true
+	t.Each(func(item T) bool {
+		subset = s.Has(item)
+		return subset
+	})
+	return subset
+}
+
+// isEqualOf tests whether s and t have the same items. Neither set is locked.
+func isEqualOf[T comparable](s, t InterfaceOf[T]) bool {
+	return s.Size() == t.Size() && isSubsetOf(s, t)
+}
+
+// formatItems returns the string representation shared by all set types.
+func formatItems[T any](items []T) string {
+	t := make([]byte, 0, 2+len(items)*4)
+	t = append(t, '[')
+	for i, item := range items {
+		if i > 0 {
+			t = append(t, ", "...)
+		}
+		t = fmt.Append(t, item)
+	}
+	t = append(t, ']')
+
+	return string(t)
+}
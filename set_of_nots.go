@@ -0,0 +1,211 @@
+package set
+
+import "iter"
+
+// Provides a common set baseline for both threadsafe and non-ts SetOf.
+type setOf[T comparable] struct {
+	m map[T]struct{}
+}
+
+// SetNonTSOf defines a non-thread safe, type-parameterized set data structure.
+type SetNonTSOf[T comparable] struct {
+	setOf[T]
+}
+
+// NewNonTSOf creates and initialize a new non-threadsafe SetNonTSOf.
+// It accepts a variable number of arguments to populate the initial set.
+// If nothing is passed a SetNonTSOf with zero size is created.
+func NewNonTSOf[T comparable](items ...T) *SetNonTSOf[T] {
+	s := &SetNonTSOf[T]{}
+	s.m = make(map[T]struct{}, len(items))
+
+	s.Add(items...)
+	return s
+}
+
+// Ensure interface compliance
+var _ InterfaceOf[int] = (*SetNonTSOf[int])(nil)
+
+// New creates and initalizes a new set of the same kind. It accepts a
+// variable number of arguments to populate the initial set.
+func (s *setOf[T]) New(items ...T) InterfaceOf[T] {
+	return NewNonTSOf(items...)
+}
+
+// Add includes the specified items (one or more) to the set. The underlying
+// set s is modified. If passed nothing it silently returns.
+func (s *setOf[T]) Add(items ...T) {
+	for _, item := range items {
+		s.m[item] = keyExists
+	}
+}
+
+// Remove deletes the specified items from the set. The underlying set s is
+// modified. If passed nothing it silently returns.
+func (s *setOf[T]) Remove(items ...T) {
+	for _, item := range items {
+		delete(s.m, item)
+	}
+}
+
+// Pop deletes and returns an item from the set. The underlying set s is
+// modified. If the set is empty, the zero value and false are returned.
+func (s *setOf[T]) Pop() (T, bool) {
+	for item := range s.m {
+		delete(s.m, item)
+		return item, true
+	}
+
+	var zero T
+	return zero, false
+}
+
+// PopN deletes and returns up to n items from the set. Fewer items are
+// returned if the set holds less than n of them.
+func (s *setOf[T]) PopN(n int) []T {
+	if n <= 0 {
+		return nil
+	}
+	if n > len(s.m) {
+		n = len(s.m)
+	}
+
+	items := make([]T, 0, n)
+	for item := range s.m {
+		if len(items) == n {
+			break
+		}
+		delete(s.m, item)
+		items = append(items, item)
+	}
+	return items
+}
+
+// PopIf deletes and returns all items for which pred returns true.
+func (s *setOf[T]) PopIf(pred func(item T) bool) []T {
+	var items []T
+	for item := range s.m {
+		if pred(item) {
+			delete(s.m, item)
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (s *setOf[T]) Has(items ...T) bool {
+	// assume checked for empty item, which not exist
+	if len(items) == 0 {
+		return false
+	}
+
+	has := true
+	for _, item := range items {
+		if _, has = s.m[item]; !has {
+			break
+		}
+	}
+	return has
+}
+
+// Size returns the number of items in a set.
+func (s *setOf[T]) Size() int {
+	return len(s.m)
+}
+
+// Clear removes all items from the set.
+func (s *setOf[T]) Clear() {
+	s.m = make(map[T]struct{})
+}
+
+// IsEmpty reports whether the set is empty.
+func (s *setOf[T]) IsEmpty() bool {
+	return s.Size() == 0
+}
+
+// IsEqual test whether s and t are the same in size and have the same items.
+func (s *setOf[T]) IsEqual(t InterfaceOf[T]) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isEqualOf[T](s, viewOf(t))
+}
+
+// IsSubset tests whether t is a subset of s.
+func (s *setOf[T]) IsSubset(t InterfaceOf[T]) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isSubsetOf[T](s, viewOf(t))
+}
+
+// IsSuperset tests whether t is a superset of s.
+func (s *setOf[T]) IsSuperset(t InterfaceOf[T]) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isSubsetOf[T](viewOf(t), s)
+}
+
+// Each traverses the items in the set, calling the provided function for each
+// set member. Traversal will continue until all items in the set have been
+// visited, or if the closure returns false.
+func (s *setOf[T]) Each(f func(item T) bool) {
+	for item := range s.m {
+		if !f(item) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over the items of the set, visiting them in the
+// same order as Each.
+func (s *setOf[T]) All() iter.Seq[T] {
+	return func(yield func(item T) bool) {
+		s.Each(yield)
+	}
+}
+
+// String returns a string representation of s
+func (s *setOf[T]) String() string {
+	return formatItems(s.List())
+}
+
+// List returns a slice of all items.
+func (s *setOf[T]) List() []T {
+	list := make([]T, 0, len(s.m))
+
+	for item := range s.m {
+		list = append(list, item)
+	}
+
+	return list
+}
+
+// Copy returns a new set with a copy of s.
+func (s *setOf[T]) Copy() InterfaceOf[T] {
+	return NewNonTSOf(s.List()...)
+}
+
+// Merge is like UnionOf, however it modifies the current set it's applied on
+// with the given t set.
+func (s *setOf[T]) Merge(t InterfaceOf[T]) {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	viewOf(t).Each(func(item T) bool {
+		s.m[item] = keyExists
+		return true
+	})
+}
+
+// Separate removes the set items containing in t from set s. Please aware that
+// it's not the opposite of Merge.
+func (s *setOf[T]) Separate(t InterfaceOf[T]) {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	s.Remove(viewOf(t).List()...)
+}
@@ -0,0 +1,68 @@
+package set
+
+import (
+	"testing"
+)
+
+func TestSetNonTSOf_New(t *testing.T) {
+	s := NewNonTSOf(1.5, 2.5, 1.5)
+	if s.Size() != 2 {
+		t.Error("NewNonTSOf: calling with parameters should create a set with size of two")
+	}
+
+	if _, ok := s.New().(*SetNonTSOf[float64]); !ok {
+		t.Error("New: should create a set of the same kind")
+	}
+}
+
+func TestSetNonTSOf_AddRemove(t *testing.T) {
+	s := NewNonTSOf[string]()
+	s.Add("fatih", "zeynep", "zeynep")
+
+	if s.Size() != 2 || !s.Has("fatih", "zeynep") {
+		t.Error("Add: added items are not availabile in the set.")
+	}
+
+	s.Remove("fatih")
+	if s.Has("fatih") || s.Has("fatih", "zeynep") {
+		t.Error("Remove: removed item is still availabile in the set.")
+	}
+}
+
+func TestSetNonTSOf_Pop(t *testing.T) {
+	s := NewNonTSOf(7)
+
+	if a, ok := s.Pop(); !ok || a != 7 || !s.IsEmpty() {
+		t.Error("Pop: should return the only item and leave the set empty")
+	}
+
+	if _, ok := s.Pop(); ok {
+		t.Error("Pop: should report false because set is empty")
+	}
+}
+
+func TestSetNonTSOf_List(t *testing.T) {
+	s := NewNonTSOf("1", "2", "3", "4")
+
+	// this returns a []string, no assertions needed
+	list := s.List()
+	if len(list) != 4 {
+		t.Error("List: slice size should be four.")
+	}
+
+	if !NewNonTSOf(list...).IsEqual(s) {
+		t.Error("List: should contain every item of the set")
+	}
+}
+
+func TestSetNonTSOf_IsEqual(t *testing.T) {
+	s := NewNonTSOf(1, 2, 3)
+
+	if !s.IsEqual(NewOf(3, 2, 1)) {
+		t.Error("IsEqual: set s and t are equal. However it returns false")
+	}
+
+	if s.IsEqual(NewNonTSOf(1, 2)) {
+		t.Error("IsEqual: different size. However it returns true")
+	}
+}
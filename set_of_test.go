@@ -0,0 +1,94 @@
+package set
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func sortedInts(s InterfaceOf[int]) []int {
+	list := s.List()
+	sort.Ints(list)
+	return list
+}
+
+func Test_UnionOf(t *testing.T) {
+	s := NewOf(1, 2, 3)
+	r := NewOf(3, 4, 5)
+	x := NewNonTSOf(5, 6, 7)
+
+	u := UnionOf[int](s, r, x)
+	if settype := reflect.TypeOf(u).String(); settype != "*set.SetOf[int]" {
+		t.Error("UnionOf should derive its set type from the first passed set, got", settype)
+	}
+
+	if !reflect.DeepEqual(sortedInts(u), []int{1, 2, 3, 4, 5, 6, 7}) {
+		t.Error("UnionOf: merged items are not availabile in the set.", u)
+	}
+
+	z := UnionOf[int](x, r)
+	if settype := reflect.TypeOf(z).String(); settype != "*set.SetNonTSOf[int]" {
+		t.Error("UnionOf should derive its set type from the first passed set, got", settype)
+	}
+}
+
+func Test_DifferenceOf(t *testing.T) {
+	s := NewOf("1", "2", "3")
+	r := NewOf("3", "4", "5")
+	x := NewOf("5", "6", "7")
+	u := DifferenceOf[string](s, r, x)
+
+	if u.Size() != 2 || !u.Has("1", "2") {
+		t.Error("DifferenceOf: items are not availabile in the set.", u)
+	}
+
+	if DifferenceOf[string](r, r).Size() != 0 {
+		t.Error("DifferenceOf: size should be zero")
+	}
+}
+
+func Test_IntersectionOf(t *testing.T) {
+	s1 := NewOf(1, 3, 4, 5)
+	s2 := NewOf(2, 3, 5, 6)
+	s3 := NewNonTSOf(4, 5, 6, 7)
+	u := IntersectionOf[int](s1, s2, s3)
+
+	if !reflect.DeepEqual(sortedInts(u), []int{5}) {
+		t.Error("IntersectionOf: items after intersection are not availabile in the set.", u)
+	}
+}
+
+func Test_SymmetricDifferenceOf(t *testing.T) {
+	s := NewOf(1, 2, 3)
+	r := NewOf(3, 4, 5)
+	u := SymmetricDifferenceOf[int](s, r)
+
+	if !reflect.DeepEqual(sortedInts(u), []int{1, 2, 4, 5}) {
+		t.Error("SymmetricDifferenceOf: items are not availabile in the set.", u)
+	}
+}
+
+func benchmarkIntersectionOf(b *testing.B, numberOfItems int) {
+	s1 := NewOf[int]()
+	s2 := NewOf[int]()
+
+	for i := 0; i < numberOfItems/2; i++ {
+		s1.Add(i)
+	}
+	for i := 0; i < numberOfItems; i++ {
+		s2.Add(i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		IntersectionOf[int](s1, s2)
+	}
+}
+
+func BenchmarkIntersectionOf1000(b *testing.B) {
+	benchmarkIntersectionOf(b, 1000)
+}
+
+func BenchmarkIntersectionOf100000(b *testing.B) {
+	benchmarkIntersectionOf(b, 100000)
+}
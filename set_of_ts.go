@@ -0,0 +1,200 @@
+package set
+
+import "iter"
+
+// SetOf defines a thread safe, type-parameterized set data structure.
+type SetOf[T comparable] struct {
+	setOf[T]
+	setLock
+}
+
+// NewOf creates and initialize a new SetOf. It's accept a variable number of
+// arguments to populate the initial set. If nothing passed a SetOf with zero
+// size is created.
+func NewOf[T comparable](items ...T) *SetOf[T] {
+	s := &SetOf[T]{}
+	s.m = make(map[T]struct{}, len(items))
+
+	s.Add(items...)
+	return s
+}
+
+// Ensure interface compliance
+var _ InterfaceOf[int] = (*SetOf[int])(nil)
+
+// New creates and initalizes a new set of the same kind. It accepts a
+// variable number of arguments to populate the initial set.
+func (s *SetOf[T]) New(items ...T) InterfaceOf[T] {
+	return NewOf(items...)
+}
+
+// Add includes the specified items (one or more) to the set. The underlying
+// set s is modified. If passed nothing it silently returns.
+func (s *SetOf[T]) Add(items ...T) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.setOf.Add(items...)
+}
+
+// Remove deletes the specified items from the set. The underlying set s is
+// modified. If passed nothing it silently returns.
+func (s *SetOf[T]) Remove(items ...T) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.setOf.Remove(items...)
+}
+
+// Pop deletes and returns an item from the set. The underlying set s is
+// modified. If the set is empty, the zero value and false are returned. The
+// item is looked up and deleted under a single write lock.
+func (s *SetOf[T]) Pop() (T, bool) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return s.setOf.Pop()
+}
+
+// PopN deletes and returns up to n items from the set under a single lock.
+// Fewer items are returned if the set holds less than n of them.
+func (s *SetOf[T]) PopN(n int) []T {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return s.setOf.PopN(n)
+}
+
+// PopIf deletes and returns all items for which pred returns true under a
+// single lock. pred must not call methods of s.
+func (s *SetOf[T]) PopIf(pred func(item T) bool) []T {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return s.setOf.PopIf(pred)
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (s *SetOf[T]) Has(items ...T) bool {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.setOf.Has(items...)
+}
+
+// Size returns the number of items in a set.
+func (s *SetOf[T]) Size() int {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return len(s.m)
+}
+
+// Clear removes all items from the set.
+func (s *SetOf[T]) Clear() {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.m = make(map[T]struct{})
+}
+
+// IsEmpty reports whether the set is empty.
+func (s *SetOf[T]) IsEmpty() bool {
+	return s.Size() == 0
+}
+
+// IsEqual test whether s and t are the same in size and have the same items.
+func (s *SetOf[T]) IsEqual(t InterfaceOf[T]) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isEqualOf[T](&s.setOf, viewOf(t))
+}
+
+// IsSubset tests whether t is a subset of s.
+func (s *SetOf[T]) IsSubset(t InterfaceOf[T]) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isSubsetOf[T](&s.setOf, viewOf(t))
+}
+
+// IsSuperset tests whether t is a superset of s.
+func (s *SetOf[T]) IsSuperset(t InterfaceOf[T]) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isSubsetOf[T](viewOf(t), &s.setOf)
+}
+
+// Each traverses the items in the set, calling the provided function for each
+// set member. Traversal will continue until all items in the set have been
+// visited, or if the closure returns false.
+func (s *SetOf[T]) Each(f func(item T) bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	s.setOf.Each(f)
+}
+
+// All returns an iterator over the items of the set, visiting them in the
+// same order as Each. As with Each, s is read locked from the start of the
+// loop until it ends, so the loop body must not modify s.
+func (s *SetOf[T]) All() iter.Seq[T] {
+	return func(yield func(item T) bool) {
+		s.Each(yield)
+	}
+}
+
+// String returns a string representation of s
+func (s *SetOf[T]) String() string {
+	return formatItems(s.List())
+}
+
+// List returns a slice of all items.
+func (s *SetOf[T]) List() []T {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.setOf.List()
+}
+
+// Copy returns a new SetOf with a copy of s.
+func (s *SetOf[T]) Copy() InterfaceOf[T] {
+	return NewOf(s.List()...)
+}
+
+// Merge is like UnionOf, however it modifies the current set it's applied on
+// with the given t set.
+func (s *SetOf[T]) Merge(t InterfaceOf[T]) {
+	unlock := lockSets(s, t)
+	defer unlock()
+
+	viewOf(t).Each(func(item T) bool {
+		s.m[item] = keyExists
+		return true
+	})
+}
+
+// Separate removes the set items containing in t from set s. Please aware that
+// it's not the opposite of Merge.
+func (s *SetOf[T]) Separate(t InterfaceOf[T]) {
+	unlock := lockSets(s, t)
+	defer unlock()
+
+	s.setOf.Remove(viewOf(t).List()...)
+}
+
+// unlocked returns the underlying set of s, which does not lock.
+func (s *SetOf[T]) unlocked() InterfaceOf[T] {
+	return &s.setOf
+}
@@ -0,0 +1,196 @@
+package set
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestSetOf_New(t *testing.T) {
+	s := NewOf[string]()
+	if s.Size() != 0 {
+		t.Error("NewOf: calling without any parameters should create a set with zero size")
+	}
+
+	u := NewOf("string", "another_string", "string")
+	if u.Size() != 2 {
+		t.Error("NewOf: calling with parameters should create a set with size of two")
+	}
+
+	if _, ok := u.New().(*SetOf[string]); !ok {
+		t.Error("New: should create a set of the same kind")
+	}
+}
+
+func TestSetOf_AddRemove(t *testing.T) {
+	s := NewOf[int]()
+	s.Add(1, 2, 2, 3)
+
+	if s.Size() != 3 || !s.Has(1, 2, 3) {
+		t.Error("Add: added items are not availabile in the set.")
+	}
+
+	s.Remove(1, 2, 42)
+	if s.Size() != 1 || s.Has(1) || !s.Has(3) {
+		t.Error("Remove: removed items are still availabile in the set.")
+	}
+
+	if s.Has() {
+		t.Error("Has: should return false if nothing is passed")
+	}
+}
+
+func TestSetOf_Pop(t *testing.T) {
+	s := NewOf("1", "2")
+
+	a, ok := s.Pop()
+	if !ok || s.Size() != 1 || s.Has(a) {
+		t.Error("Pop: returned item should not exist")
+	}
+
+	s.Pop()
+	b, ok := s.Pop()
+	if ok || b != "" {
+		t.Error("Pop: should return the zero value because set is empty")
+	}
+}
+
+func TestSetOf_PopNPopIf(t *testing.T) {
+	s := NewOf(1, 2, 3, 4, 5, 6)
+
+	even := s.PopIf(func(item int) bool { return item%2 == 0 })
+	if len(even) != 3 || s.Has(2) || s.Has(4) || s.Has(6) {
+		t.Error("PopIf: should remove and return the even items")
+	}
+
+	odd := s.PopN(5)
+	if len(odd) != 3 || !s.IsEmpty() {
+		t.Error("PopN: should return all remaining items when n exceeds the size")
+	}
+}
+
+func TestSetOf_Clear(t *testing.T) {
+	s := NewOf(1, 2, 3)
+	s.Clear()
+
+	if !s.IsEmpty() {
+		t.Error("Clear: set should be empty")
+	}
+}
+
+func TestSetOf_IsEqual(t *testing.T) {
+	s := NewOf(1, 2, 3)
+
+	if !s.IsEqual(NewNonTSOf(1, 2, 3)) {
+		t.Error("IsEqual: set s and t are equal. However it returns false")
+	}
+
+	if s.IsEqual(NewOf(4, 5, 6)) {
+		t.Error("IsEqual: same size, different content. However it returns true")
+	}
+
+	if s.IsEqual(NewOf(1, 2, 3, 4)) {
+		t.Error("IsEqual: different size, similar content. However it returns true")
+	}
+}
+
+func TestSetOf_IsSubsetSuperset(t *testing.T) {
+	s := NewOf(1, 2, 3, 4)
+	u := NewOf(1, 2, 3)
+
+	if !s.IsSubset(u) || u.IsSubset(s) {
+		t.Error("IsSubset: u is a subset of s and not the other way around")
+	}
+
+	if !u.IsSuperset(s) || s.IsSuperset(u) {
+		t.Error("IsSuperset: s is a superset of u and not the other way around")
+	}
+}
+
+func TestSetOf_String(t *testing.T) {
+	if s := NewOf[string]().String(); s != "[]" {
+		t.Errorf("String: output is not what is excepted '%s'", s)
+	}
+
+	if s := NewOf("a").String(); s != "[a]" {
+		t.Errorf("String: output is not what is excepted '%s'", s)
+	}
+}
+
+func TestSetOf_Copy(t *testing.T) {
+	s := NewOf(1, 2, 3, 4)
+	r := s.Copy()
+
+	if !s.IsEqual(r) {
+		t.Error("Copy: set s and r are not equal")
+	}
+
+	r.Add(5)
+	if s.Has(5) {
+		t.Error("Copy: modifying the copy should not modify the original")
+	}
+}
+
+func TestSetOf_MergeSeparate(t *testing.T) {
+	s := NewOf(1, 2, 3)
+	s.Merge(NewOf(3, 4, 5))
+
+	if s.Size() != 5 || !s.Has(1, 2, 3, 4, 5) {
+		t.Error("Merge: merged items are not availabile in the set.")
+	}
+
+	s.Separate(NewNonTSOf(3, 5))
+	if s.Size() != 3 || !s.Has(1, 2, 4) {
+		t.Error("Separate: items after separation are not availabile in the set.")
+	}
+}
+
+func TestSetOf_RaceAdd(t *testing.T) {
+	s := NewOf[string]()
+	u := NewOf[string]()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 1000; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			item := "item" + strconv.Itoa(i)
+			s.Add(item)
+			u.Add(item)
+			s.Has(item)
+			u.Remove(item)
+		}(i)
+	}
+	wg.Wait()
+
+	if s.Size() != 1000 || !u.IsEmpty() {
+		t.Error("Add: concurrent adds and removes lost items")
+	}
+}
+
+func TestSetOf_CrossLocking(t *testing.T) {
+	a := NewOf[int]()
+	b := NewOf[int]()
+
+	var wg sync.WaitGroup
+	op := func(s, u *SetOf[int]) {
+		defer wg.Done()
+		for i := 0; i < 500; i++ {
+			s.Add(i)
+			s.Merge(u)
+			s.Merge(s)
+			s.IsEqual(u)
+			s.IsSuperset(u)
+			UnionOf[int](s, u)
+			IntersectionOf[int](u, s)
+			SymmetricDifferenceOf[int](u, s)
+			s.Separate(u)
+			u.Remove(i)
+		}
+	}
+
+	wg.Add(2)
+	go op(a, b)
+	go op(b, a)
+	wg.Wait()
+}
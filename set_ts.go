@@ -1,13 +1,16 @@
 package set
 
 import (
-	"sync"
+	"iter"
+	"maps"
+	"sync/atomic"
 )
 
 // Set defines a thread safe set data structure.
 type Set struct {
 	set
-	l sync.RWMutex // we name it because we don't want to expose it
+	setLock
+	shared atomic.Bool // m is shared with a snapshot, see Snapshot
 }
 
 // New creates and initialize a new Set. It's accept a variable number of
@@ -28,22 +31,27 @@ func New(items ...interface{}) *Set {
 // number of arguments to populate the initial set. If nothing is passed a
 // zero size Set based on the struct is created.
 func (s *Set) New(items ...interface{}) Interface {
-	return New(items...)
+	n := New()
+	n.validate = s.validate
+	n.Add(items...)
+	return n
 }
 
 // Add includes the specified items (one or more) to the set. The underlying
-// Set s is modified. If passed nothing it silently returns.
+// Set s is modified. If passed nothing it silently returns. See TryAdd and
+// ValidateItems for batches which may contain unhashable items.
 func (s *Set) Add(items ...interface{}) {
 	if len(items) == 0 {
 		return
 	}
 
+	s.checkItems(items)
+
 	s.l.Lock()
 	defer s.l.Unlock()
 
-	for _, item := range items {
-		s.m[item] = keyExists
-	}
+	s.own()
+	s.add(items)
 }
 
 // Remove deletes the specified items from the set.  The underlying Set s is
@@ -56,24 +64,42 @@ func (s *Set) Remove(items ...interface{}) {
 	s.l.Lock()
 	defer s.l.Unlock()
 
+	s.own()
 	for _, item := range items {
 		delete(s.m, item)
 	}
 }
 
-// Pop  deletes and return an item from the set. The underlying Set s is
-// modified. If set is empty, nil is returned.
+// Pop deletes and return an item from the set. The underlying Set s is
+// modified. If set is empty, nil is returned. The item is looked up and
+// deleted under a single write lock, so concurrent callers never receive the
+// same item.
 func (s *Set) Pop() interface{} {
-	s.l.RLock()
-	for item := range s.m {
-		s.l.RUnlock()
-		s.l.Lock()
-		delete(s.m, item)
-		s.l.Unlock()
-		return item
-	}
-	s.l.RUnlock()
-	return nil
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.own()
+	return s.set.Pop()
+}
+
+// PopN deletes and returns up to n items from the set under a single lock.
+// Fewer items are returned if the set holds less than n of them.
+func (s *Set) PopN(n int) []interface{} {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.own()
+	return s.set.PopN(n)
+}
+
+// PopIf deletes and returns all items for which pred returns true under a
+// single lock. pred must not call methods of s.
+func (s *Set) PopIf(pred func(item interface{}) bool) []interface{} {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.own()
+	return s.set.PopIf(pred)
 }
 
 // Has looks for the existence of items passed. It returns false if nothing is
@@ -111,51 +137,42 @@ func (s *Set) Clear() {
 	defer s.l.Unlock()
 
 	s.m = make(map[interface{}]struct{})
+	s.shared.Store(false)
+}
+
+// IsEmpty reports whether the Set is empty.
+func (s *Set) IsEmpty() bool {
+	return s.Size() == 0
 }
 
 // IsEqual test whether s and t are the same in size and have the same items.
 func (s *Set) IsEqual(t Interface) bool {
-	s.l.RLock()
-	defer s.l.RUnlock()
-
-	// Force locking only if given set is threadsafe.
-	if conv, ok := t.(*Set); ok {
-		conv.l.RLock()
-		defer conv.l.RUnlock()
-	}
+	unlock := lockSets(nil, s, t)
+	defer unlock()
 
-	// return false if they are no the same size
-	if sameSize := len(s.m) == t.Size(); !sameSize {
-		return false
-	}
-
-	equal := true
-	t.Each(func(item interface{}) bool {
-		_, equal = s.m[item]
-		return equal // if false, Each() will end
-	})
-
-	return equal
+	return isEqual(&s.set, view(t))
 }
 
 // IsSubset tests whether t is a subset of s.
-func (s *Set) IsSubset(t Interface) (subset bool) {
-	s.l.RLock()
-	defer s.l.RUnlock()
+func (s *Set) IsSubset(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
 
-	subset = true
+	return isSubset(&s.set, view(t))
+}
 
-	t.Each(func(item interface{}) bool {
-		_, subset = s.m[item]
-		return subset
-	})
+// IsSuperset tests whether t is a superset of s.
+func (s *Set) IsSuperset(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
 
-	return
+	return isSubset(view(t), &s.set)
 }
 
 // Each traverses the items in the Set, calling the provided function for each
 // set member. Traversal will continue until all items in the Set have been
-// visited, or if the closure returns false.
+// visited, or if the closure returns false. s is read locked during the
+// traversal, so the closure must not modify s; traverse a Snapshot instead.
 func (s *Set) Each(f func(item interface{}) bool) {
 	s.l.RLock()
 	defer s.l.RUnlock()
@@ -167,6 +184,16 @@ func (s *Set) Each(f func(item interface{}) bool) {
 	}
 }
 
+// All returns an iterator over the items of the set, visiting them in the
+// same order as Each. As with Each, s is read locked from the start of the
+// loop until it ends, so the loop body must not modify s.
+// Range over the All of a Snapshot to modify s in the loop.
+func (s *Set) All() iter.Seq[interface{}] {
+	return func(yield func(item interface{}) bool) {
+		s.Each(yield)
+	}
+}
+
 // List returns a slice of all items. There is also StringSlice() and
 // IntSlice() methods for returning slices of type string or int.
 func (s *Set) List() []interface{} {
@@ -184,17 +211,50 @@ func (s *Set) List() []interface{} {
 
 // Copy returns a new Set with a copy of s.
 func (s *Set) Copy() Interface {
-	return New(s.List()...)
+	return s.New(s.List()...)
+}
+
+// String returns a string representation of s
+func (s *Set) String() string {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.set.String()
 }
 
 // Merge is like Union, however it modifies the current set it's applied on
 // with the given t set.
 func (s *Set) Merge(t Interface) {
-	s.l.Lock()
-	defer s.l.Unlock()
+	unlock := lockSets(s, t)
+	defer unlock()
 
-	t.Each(func(item interface{}) bool {
+	s.own()
+	view(t).Each(func(item interface{}) bool {
 		s.m[item] = keyExists
 		return true
 	})
 }
+
+// Separate removes the set items containing in t from set s. Please aware that
+// it's not the opposite of Merge.
+func (s *Set) Separate(t Interface) {
+	unlock := lockSets(s, t)
+	defer unlock()
+
+	s.own()
+	s.set.Remove(view(t).List()...)
+}
+
+// own copies the items of s if they are shared with a snapshot, so that s
+// can be modified. s must be write locked.
+func (s *Set) own() {
+	if s.shared.Load() {
+		s.m = maps.Clone(s.m)
+		s.shared.Store(false)
+	}
+}
+
+// unlocked returns the underlying set of s, which does not lock.
+func (s *Set) unlocked() Interface {
+	return &s.set
+}
@@ -4,7 +4,9 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestSet_New(t *testing.T) {
@@ -123,6 +125,108 @@ func TestSet_Pop(t *testing.T) {
 	s.Pop() // try to remove something from a zero length set
 }
 
+func TestSet_PopN(t *testing.T) {
+	s := New(1, 2, 3, 4, 5)
+
+	items := s.PopN(2)
+	if len(items) != 2 || s.Size() != 3 {
+		t.Error("PopN: should return two items and leave three in the set")
+	}
+
+	for _, item := range items {
+		if s.Has(item) {
+			t.Error("PopN: returned item should not exist")
+		}
+	}
+
+	if items = s.PopN(10); len(items) != 3 || !s.IsEmpty() {
+		t.Error("PopN: should return all remaining items when n exceeds the size")
+	}
+
+	if items = s.PopN(0); len(items) != 0 {
+		t.Error("PopN: should return nothing for n <= 0")
+	}
+}
+
+func TestSet_PopIf(t *testing.T) {
+	s := New(1, 2, 3, 4, 5, "six")
+
+	even := s.PopIf(func(item interface{}) bool {
+		i, ok := item.(int)
+		return ok && i%2 == 0
+	})
+
+	if len(even) != 2 || s.Size() != 4 {
+		t.Error("PopIf: should return the two even items")
+	}
+
+	if s.Has(2) || s.Has(4) || !s.Has(1, 3, 5, "six") {
+		t.Error("PopIf: only matching items should be removed")
+	}
+}
+
+func TestSet_RacePop(t *testing.T) {
+	// Pop the same set from many goroutines and make sure every item is
+	// handed out exactly once. "go test -race" checks the locking.
+	const items = 10000
+	const workers = 16
+
+	s := New()
+	for i := 0; i < items; i++ {
+		s.Add(i)
+	}
+
+	var wg sync.WaitGroup
+	results := make([][]interface{}, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for {
+				var got []interface{}
+				switch w % 3 {
+				case 0:
+					if item := s.Pop(); item != nil {
+						got = []interface{}{item}
+					}
+				case 1:
+					got = s.PopN(7)
+				default:
+					got = s.PopIf(func(item interface{}) bool {
+						return item.(int)%workers == w
+					})
+					if len(got) == 0 {
+						got = s.PopN(1)
+					}
+				}
+
+				if len(got) == 0 {
+					return
+				}
+				results[w] = append(results[w], got...)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	seen := make(map[interface{}]int, items)
+	for _, r := range results {
+		for _, item := range r {
+			seen[item]++
+		}
+	}
+
+	if len(seen) != items {
+		t.Errorf("Pop: %d distinct items popped, want %d", len(seen), items)
+	}
+
+	for item, n := range seen {
+		if n != 1 {
+			t.Errorf("Pop: item %v popped %d times", item, n)
+		}
+	}
+}
+
 func TestSet_Has(t *testing.T) {
 	s := New("1", "2", "3", "4")
 
@@ -319,3 +423,67 @@ func TestSet_RaceAdd(t *testing.T) {
 		}(i)
 	}
 }
+
+func TestSet_MergeSelf(t *testing.T) {
+	s := New(1, 2, 3)
+
+	done := make(chan struct{})
+	go func() {
+		s.Merge(s)
+		s.IsEqual(s)
+		s.IsSubset(s)
+		s.Separate(s)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Merge: operations of a set with itself deadlocked")
+	}
+
+	if !s.IsEmpty() {
+		t.Error("Separate: separating a set from itself should leave it empty")
+	}
+}
+
+func TestSet_CrossLocking(t *testing.T) {
+	// Run two-set operations in both directions concurrently with writers.
+	// Without a global lock order a.Merge(b) and b.Merge(a) deadlock.
+	a := New()
+	b := New()
+
+	var wg sync.WaitGroup
+	op := func(s, u *Set) {
+		defer wg.Done()
+		for i := 0; i < 500; i++ {
+			s.Add(i)
+			s.Merge(u)
+			s.IsEqual(u)
+			s.IsSubset(u)
+			s.IsSuperset(u)
+			Union(s, u)
+			Intersection(u, s)
+			Difference(s, u, s)
+			SymmetricDifference(u, s)
+			s.Separate(u)
+			u.Remove(i)
+		}
+	}
+
+	wg.Add(2)
+	go op(a, b)
+	go op(b, a)
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		t.Fatal("operations on two sets deadlocked")
+	}
+}
@@ -0,0 +1,432 @@
+package set
+
+import (
+	"hash/maphash"
+	"iter"
+	"runtime"
+	"sync"
+)
+
+// shard is one lock-striped part of a ShardedSet.
+type shard struct {
+	l sync.RWMutex
+	m map[interface{}]struct{}
+	_ [64]byte // keep the locks of neighbouring shards off one cache line
+}
+
+// Provides the baseline of ShardedSet. shardedSet itself never locks, it is
+// the view ShardedSet hands out to operations that already hold its locks.
+type shardedSet struct {
+	shards []shard
+	mask   uint64
+	seed   maphash.Seed
+}
+
+// ShardedSet defines a thread safe set data structure for high write
+// contention. Items are spread by hash over a fixed number of shards, each
+// guarded by its own lock, so goroutines adding or looking up different items
+// rarely wait on each other.
+//
+// Add, Remove, Pop and Has lock only the shards of the items involved; when
+// passed several items they are not atomic as a whole. All other operations
+// (Size, List, Each, String, Copy, IsEqual, ...) lock every shard at once and
+// therefore work on a snapshot of the set taken at one point in time.
+type ShardedSet struct {
+	shardedSet
+	lockOrder
+}
+
+// NewSharded creates and initialize a new ShardedSet with the given number of
+// shards, rounded up to a power of two. If shards is zero or negative four
+// shards per GOMAXPROCS are used. It accepts a variable number of arguments
+// to populate the initial set.
+func NewSharded(shards int, items ...interface{}) *ShardedSet {
+	if shards <= 0 {
+		shards = 4 * runtime.GOMAXPROCS(0)
+	}
+
+	n := 1
+	for n < shards {
+		n <<= 1
+	}
+
+	s := &ShardedSet{}
+	s.shards = make([]shard, n)
+	s.mask = uint64(n - 1)
+	s.seed = maphash.MakeSeed()
+	for i := range s.shards {
+		s.shards[i].m = make(map[interface{}]struct{})
+	}
+
+	// Ensure interface compliance
+	var _ Interface = s
+
+	s.Add(items...)
+	return s
+}
+
+// Shards returns the number of shards of s.
+func (s *shardedSet) Shards() int {
+	return len(s.shards)
+}
+
+// shardFor returns the shard item belongs to.
+func (s *shardedSet) shardFor(item interface{}) *shard {
+	var h uint64
+	switch v := item.(type) {
+	case string:
+		h = maphash.String(s.seed, v)
+	default:
+		h = maphash.Comparable(s.seed, item)
+	}
+	return &s.shards[h&s.mask]
+}
+
+// New creates and initalizes a new ShardedSet with the same number of shards.
+func (s *shardedSet) New(items ...interface{}) Interface {
+	return NewSharded(len(s.shards), items...)
+}
+
+// Add includes the specified items (one or more) to the set.
+func (s *shardedSet) Add(items ...interface{}) {
+	for _, item := range items {
+		s.shardFor(item).m[item] = keyExists
+	}
+}
+
+// Remove deletes the specified items from the set.
+func (s *shardedSet) Remove(items ...interface{}) {
+	for _, item := range items {
+		delete(s.shardFor(item).m, item)
+	}
+}
+
+// Pop deletes and return an item from the set. If set is empty, nil is
+// returned.
+func (s *shardedSet) Pop() interface{} {
+	for i := range s.shards {
+		for item := range s.shards[i].m {
+			delete(s.shards[i].m, item)
+			return item
+		}
+	}
+	return nil
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (s *shardedSet) Has(items ...interface{}) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	for _, item := range items {
+		if _, has := s.shardFor(item).m[item]; !has {
+			return false
+		}
+	}
+	return true
+}
+
+// Size returns the number of items in a set.
+func (s *shardedSet) Size() int {
+	n := 0
+	for i := range s.shards {
+		n += len(s.shards[i].m)
+	}
+	return n
+}
+
+// Clear removes all items from the set.
+func (s *shardedSet) Clear() {
+	for i := range s.shards {
+		s.shards[i].m = make(map[interface{}]struct{})
+	}
+}
+
+// IsEmpty reports whether the set is empty.
+func (s *shardedSet) IsEmpty() bool {
+	return s.Size() == 0
+}
+
+// IsEqual test whether s and t are the same in size and have the same items.
+func (s *shardedSet) IsEqual(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isEqual(s, view(t))
+}
+
+// IsSubset tests whether t is a subset of s.
+func (s *shardedSet) IsSubset(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isSubset(s, view(t))
+}
+
+// IsSuperset tests whether t is a superset of s.
+func (s *shardedSet) IsSuperset(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isSubset(view(t), s)
+}
+
+// Each traverses the items in the set, calling the provided function for each
+// set member. Traversal will continue until all items in the set have been
+// visited, or if the closure returns false.
+func (s *shardedSet) Each(f func(item interface{}) bool) {
+	for i := range s.shards {
+		for item := range s.shards[i].m {
+			if !f(item) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the items of the set, visiting them in the
+// same order as Each.
+func (s *shardedSet) All() iter.Seq[interface{}] {
+	return func(yield func(item interface{}) bool) {
+		s.Each(yield)
+	}
+}
+
+// String returns a string representation of s
+func (s *shardedSet) String() string {
+	return formatItems(s.List())
+}
+
+// List returns a slice of all items.
+func (s *shardedSet) List() []interface{} {
+	list := make([]interface{}, 0, s.Size())
+	for i := range s.shards {
+		for item := range s.shards[i].m {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Copy returns a new ShardedSet with a copy of s.
+func (s *shardedSet) Copy() Interface {
+	return NewSharded(len(s.shards), s.List()...)
+}
+
+// Merge is like Union, however it modifies the current set it's applied on
+// with the given t set.
+func (s *shardedSet) Merge(t Interface) {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	view(t).Each(func(item interface{}) bool {
+		s.shardFor(item).m[item] = keyExists
+		return true
+	})
+}
+
+// Separate removes the set items containing in t from set s. Please aware that
+// it's not the opposite of Merge.
+func (s *shardedSet) Separate(t Interface) {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	s.Remove(view(t).List()...)
+}
+
+// New creates and initalizes a new ShardedSet with the same number of shards.
+func (s *ShardedSet) New(items ...interface{}) Interface {
+	return NewSharded(len(s.shards), items...)
+}
+
+// Add includes the specified items (one or more) to the set. The underlying
+// set s is modified. If passed nothing it silently returns.
+func (s *ShardedSet) Add(items ...interface{}) {
+	for _, item := range items {
+		sh := s.shardFor(item)
+		sh.l.Lock()
+		sh.m[item] = keyExists
+		sh.l.Unlock()
+	}
+}
+
+// Remove deletes the specified items from the set. The underlying set s is
+// modified. If passed nothing it silently returns.
+func (s *ShardedSet) Remove(items ...interface{}) {
+	for _, item := range items {
+		sh := s.shardFor(item)
+		sh.l.Lock()
+		delete(sh.m, item)
+		sh.l.Unlock()
+	}
+}
+
+// Pop deletes and return an item from the set. If set is empty, nil is
+// returned. Each shard is looked at under its own write lock, so concurrent
+// callers never receive the same item.
+func (s *ShardedSet) Pop() interface{} {
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.l.Lock()
+		for item := range sh.m {
+			delete(sh.m, item)
+			sh.l.Unlock()
+			return item
+		}
+		sh.l.Unlock()
+	}
+	return nil
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (s *ShardedSet) Has(items ...interface{}) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	for _, item := range items {
+		sh := s.shardFor(item)
+		sh.l.RLock()
+		_, has := sh.m[item]
+		sh.l.RUnlock()
+
+		if !has {
+			return false
+		}
+	}
+	return true
+}
+
+// Size returns the number of items in a set.
+func (s *ShardedSet) Size() int {
+	s.lock(false)
+	defer s.unlock(false)
+
+	return s.shardedSet.Size()
+}
+
+// Clear removes all items from the set.
+func (s *ShardedSet) Clear() {
+	s.lock(true)
+	defer s.unlock(true)
+
+	s.shardedSet.Clear()
+}
+
+// IsEmpty reports whether the set is empty.
+func (s *ShardedSet) IsEmpty() bool {
+	return s.Size() == 0
+}
+
+// IsEqual test whether s and t are the same in size and have the same items.
+func (s *ShardedSet) IsEqual(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isEqual(&s.shardedSet, view(t))
+}
+
+// IsSubset tests whether t is a subset of s.
+func (s *ShardedSet) IsSubset(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isSubset(&s.shardedSet, view(t))
+}
+
+// IsSuperset tests whether t is a superset of s.
+func (s *ShardedSet) IsSuperset(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isSubset(view(t), &s.shardedSet)
+}
+
+// Each traverses the items in the set, calling the provided function for each
+// set member. Traversal will continue until all items in the set have been
+// visited, or if the closure returns false. All shards are read locked while
+// f runs, so f must not modify s.
+func (s *ShardedSet) Each(f func(item interface{}) bool) {
+	s.lock(false)
+	defer s.unlock(false)
+
+	s.shardedSet.Each(f)
+}
+
+// All returns an iterator over the items of the set. As with Each, all shards
+// are read locked from the start of the loop until it ends, so the loop body
+// must not modify s.
+func (s *ShardedSet) All() iter.Seq[interface{}] {
+	return func(yield func(item interface{}) bool) {
+		s.Each(yield)
+	}
+}
+
+// String returns a string representation of s
+func (s *ShardedSet) String() string {
+	return formatItems(s.List())
+}
+
+// List returns a slice of all items.
+func (s *ShardedSet) List() []interface{} {
+	s.lock(false)
+	defer s.unlock(false)
+
+	return s.shardedSet.List()
+}
+
+// Copy returns a new ShardedSet with a copy of s.
+func (s *ShardedSet) Copy() Interface {
+	return NewSharded(len(s.shards), s.List()...)
+}
+
+// Merge is like Union, however it modifies the current set it's applied on
+// with the given t set.
+func (s *ShardedSet) Merge(t Interface) {
+	unlock := lockSets(s, t)
+	defer unlock()
+
+	view(t).Each(func(item interface{}) bool {
+		s.shardFor(item).m[item] = keyExists
+		return true
+	})
+}
+
+// Separate removes the set items containing in t from set s. Please aware that
+// it's not the opposite of Merge.
+func (s *ShardedSet) Separate(t Interface) {
+	unlock := lockSets(s, t)
+	defer unlock()
+
+	s.shardedSet.Remove(view(t).List()...)
+}
+
+// lock locks all shards of s in shard order.
+func (s *ShardedSet) lock(write bool) {
+	for i := range s.shards {
+		if write {
+			s.shards[i].l.Lock()
+		} else {
+			s.shards[i].l.RLock()
+		}
+	}
+}
+
+// unlock unlocks all shards of s in reverse shard order.
+func (s *ShardedSet) unlock(write bool) {
+	for i := len(s.shards) - 1; i >= 0; i-- {
+		if write {
+			s.shards[i].l.Unlock()
+		} else {
+			s.shards[i].l.RUnlock()
+		}
+	}
+}
+
+// unlocked returns the underlying shards of s, which do not lock.
+func (s *ShardedSet) unlocked() Interface {
+	return &s.shardedSet
+}
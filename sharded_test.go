@@ -0,0 +1,161 @@
+package set
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestShardedSet_New(t *testing.T) {
+	s := NewSharded(5, "string", "another_string", 1, 3.14)
+
+	if s.Shards() != 8 {
+		t.Error("NewSharded: shard count should be rounded up to a power of two, got", s.Shards())
+	}
+
+	if s.Size() != 4 {
+		t.Error("NewSharded: calling with parameters should create a set with size of four")
+	}
+
+	if NewSharded(0).Shards() < runtime.GOMAXPROCS(0) {
+		t.Error("NewSharded: default shard count should scale with GOMAXPROCS")
+	}
+
+	if u, ok := s.New().(*ShardedSet); !ok || u.Shards() != s.Shards() {
+		t.Error("New: should create a ShardedSet with the same number of shards")
+	}
+}
+
+func TestShardedSet_AddRemoveHas(t *testing.T) {
+	s := NewSharded(4)
+	for i := 0; i < 100; i++ {
+		s.Add(i, strconv.Itoa(i))
+	}
+
+	if s.Size() != 200 || !s.Has(0, "0", 99, "99") {
+		t.Error("Add: added items are not availabile in the set.")
+	}
+
+	s.Remove(0, "0", 42)
+	if s.Has(0) || s.Has("0") || s.Has(42) || !s.Has("42") || s.Size() != 197 {
+		t.Error("Remove: removed items are still availabile in the set.")
+	}
+
+	if s.Has() {
+		t.Error("Has: should return false if nothing is passed")
+	}
+}
+
+func TestShardedSet_Pop(t *testing.T) {
+	s := NewSharded(8, 1, 2, 3)
+
+	for i := 0; i < 3; i++ {
+		if item := s.Pop(); item == nil || s.Has(item) {
+			t.Error("Pop: returned item should not exist")
+		}
+	}
+
+	if s.Pop() != nil || !s.IsEmpty() {
+		t.Error("Pop: should return nil because set is empty")
+	}
+}
+
+func TestShardedSet_Operations(t *testing.T) {
+	s := NewSharded(4, "1", "2", "3")
+	u := New("3", "4", "5")
+
+	if !s.IsEqual(NewNonTS("1", "2", "3")) || s.IsEqual(u) {
+		t.Error("IsEqual: wrong result against another set type")
+	}
+
+	if !s.IsSubset(New("1")) || !New("1").IsSuperset(s) {
+		t.Error("IsSubset: [1] is a subset of s")
+	}
+
+	c := s.Copy()
+	if _, ok := c.(*ShardedSet); !ok || !c.IsEqual(s) {
+		t.Error("Copy: copy should be an equal ShardedSet")
+	}
+
+	s.Merge(u)
+	if !s.Has("1", "2", "3", "4", "5") || s.Size() != 5 {
+		t.Error("Merge: merged items are not availabile in the set.")
+	}
+
+	s.Separate(u)
+	if !s.IsEqual(New("1", "2")) {
+		t.Error("Separate: items after separation are not availabile in the set.")
+	}
+
+	if x := Union(s, u); x.Size() != 5 {
+		t.Error("Union: the merged set doesn't have all items in it.")
+	} else if _, ok := x.(*ShardedSet); !ok {
+		t.Error("Union should derive its set type from the first passed set")
+	}
+
+	if x := Intersection(u, NewSharded(2, "4", "6")); !x.IsEqual(New("4")) {
+		t.Error("Intersection: items after intersection are not availabile in the set.")
+	}
+
+	s.Clear()
+	if !s.IsEmpty() || s.String() != "[]" {
+		t.Error("Clear: set should be empty")
+	}
+}
+
+func TestShardedSet_Race(t *testing.T) {
+	s := NewSharded(8)
+	u := NewSharded(8)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				item := g*1000 + i
+				s.Add(item)
+				u.Merge(s)
+				s.IsEqual(u)
+				s.Size()
+				u.Remove(item)
+				s.Pop()
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func benchmarkConcurrent(b *testing.B, newSet func() Interface) {
+	for _, procs := range []int{1, 4, 16, 64} {
+		b.Run(fmt.Sprintf("GOMAXPROCS=%d", procs), func(b *testing.B) {
+			defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(procs))
+
+			s := newSet()
+			var worker int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := int(atomic.AddInt64(&worker, 1)) << 32
+				for pb.Next() {
+					i++
+					if i%4 == 0 {
+						s.Add(i)
+					} else {
+						s.Has(i)
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkSet_Concurrent(b *testing.B) {
+	benchmarkConcurrent(b, func() Interface { return New() })
+}
+
+func BenchmarkShardedSet_Concurrent(b *testing.B) {
+	benchmarkConcurrent(b, func() Interface { return NewSharded(64) })
+}
@@ -0,0 +1,139 @@
+package set
+
+import (
+	"errors"
+	"iter"
+)
+
+// ErrReadOnly is the panic value of the methods of a SetSnapshot which would
+// modify it.
+var ErrReadOnly = errors.New("set: snapshot is read-only")
+
+// SetSnapshot is a read-only view of the items of a Set at one moment, see
+// Set.Snapshot. It implements Interface so it can be passed to Union,
+// Intersection, Difference and the other functions of this package; the
+// methods which would modify it panic with ErrReadOnly.
+//
+// A snapshot never changes and is never locked, so it is safe for concurrent
+// use and reading it never blocks writers of its Set.
+type SetSnapshot struct {
+	s set
+}
+
+// Snapshot returns a read-only view of the items of s at this moment. It
+// takes constant time: s and the snapshot share their items until s is next
+// modified, which copies them once.
+//
+// Use it instead of Each or All for long traversals, which would block
+// writers of s, or whose callbacks modify s.
+func (s *Set) Snapshot() *SetSnapshot {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	s.shared.Store(true)
+	return &SetSnapshot{s: set{m: s.m, validate: s.validate}}
+}
+
+// New creates and initalizes a new Set, which unlike the snapshot can be
+// modified. It accepts a variable number of arguments to populate the set.
+func (s *SetSnapshot) New(items ...interface{}) Interface {
+	n := New()
+	n.validate = s.s.validate
+	n.Add(items...)
+	return n
+}
+
+// Add panics with ErrReadOnly.
+func (s *SetSnapshot) Add(items ...interface{}) {
+	panic(ErrReadOnly)
+}
+
+// Remove panics with ErrReadOnly.
+func (s *SetSnapshot) Remove(items ...interface{}) {
+	panic(ErrReadOnly)
+}
+
+// Pop panics with ErrReadOnly.
+func (s *SetSnapshot) Pop() interface{} {
+	panic(ErrReadOnly)
+}
+
+// Clear panics with ErrReadOnly.
+func (s *SetSnapshot) Clear() {
+	panic(ErrReadOnly)
+}
+
+// Merge panics with ErrReadOnly.
+func (s *SetSnapshot) Merge(t Interface) {
+	panic(ErrReadOnly)
+}
+
+// Separate panics with ErrReadOnly.
+func (s *SetSnapshot) Separate(t Interface) {
+	panic(ErrReadOnly)
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (s *SetSnapshot) Has(items ...interface{}) bool {
+	return s.s.Has(items...)
+}
+
+// Size returns the number of items in the snapshot.
+func (s *SetSnapshot) Size() int {
+	return s.s.Size()
+}
+
+// IsEmpty reports whether the snapshot is empty.
+func (s *SetSnapshot) IsEmpty() bool {
+	return s.s.IsEmpty()
+}
+
+// IsEqual test whether s and t are the same in size and have the same items.
+func (s *SetSnapshot) IsEqual(t Interface) bool {
+	return s.s.IsEqual(t)
+}
+
+// IsSubset tests whether t is a subset of s.
+func (s *SetSnapshot) IsSubset(t Interface) bool {
+	return s.s.IsSubset(t)
+}
+
+// IsSuperset tests whether t is a superset of s.
+func (s *SetSnapshot) IsSuperset(t Interface) bool {
+	return s.s.IsSuperset(t)
+}
+
+// Each traverses the items in the snapshot, calling the provided function for
+// each member. Traversal will continue until all items have been visited, or
+// if the closure returns false. The closure may modify the Set the snapshot
+// was taken of.
+func (s *SetSnapshot) Each(f func(item interface{}) bool) {
+	s.s.Each(f)
+}
+
+// All returns an iterator over the items of the snapshot, visiting them in
+// the same order as Each.
+func (s *SetSnapshot) All() iter.Seq[interface{}] {
+	return s.s.All()
+}
+
+// String returns a string representation of s
+func (s *SetSnapshot) String() string {
+	return s.s.String()
+}
+
+// List returns a slice of all items.
+func (s *SetSnapshot) List() []interface{} {
+	return s.s.List()
+}
+
+// Copy returns a new Set with a copy of s.
+func (s *SetSnapshot) Copy() Interface {
+	return s.New(s.List()...)
+}
+
+// items returns the map of s, which must not be modified.
+func (s *SetSnapshot) items() map[interface{}]struct{} {
+	return s.s.m
+}
@@ -0,0 +1,148 @@
+package set
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestSet_Snapshot(t *testing.T) {
+	s := New("a", "b", "c")
+	snap := s.Snapshot()
+
+	s.Add("d")
+	s.Remove("a")
+	if !snap.IsEqual(New("a", "b", "c")) {
+		t.Error("Snapshot: should not change when the set is modified, got", snap)
+	}
+	if !s.IsEqual(New("b", "c", "d")) {
+		t.Error("Snapshot: should not keep the set from being modified, got", s)
+	}
+
+	again := s.Snapshot()
+	s.Clear()
+	if !again.IsEqual(New("b", "c", "d")) || !s.IsEmpty() {
+		t.Error("Clear: should not change a snapshot, got", again)
+	}
+	if !snap.IsEqual(New("a", "b", "c")) {
+		t.Error("Snapshot: should not change when a later snapshot is taken, got", snap)
+	}
+}
+
+func TestSet_Snapshot_writers(t *testing.T) {
+	s := New(1, 2, 3)
+	for _, write := range []func(){
+		func() { s.Add(4) },
+		func() { s.Remove(1) },
+		func() { s.Pop() },
+		func() { s.PopN(1) },
+		func() { s.PopIf(func(item interface{}) bool { return true }) },
+		func() { s.Merge(New(5)) },
+		func() { s.Separate(New(2)) },
+		func() { _ = s.TryAdd(6) },
+		func() { _ = s.UnmarshalJSON([]byte(`["x"]`)) },
+	} {
+		snap := s.Snapshot()
+		before := snap.List()
+
+		write()
+		if !snap.IsEqual(NewNonTS(before...)) {
+			t.Error("Snapshot: should not change when the set is modified, got", snap)
+		}
+	}
+}
+
+func TestSetSnapshot_Each(t *testing.T) {
+	s := New(1, 2, 3)
+
+	// the callback modifies s, which deadlocks with Set.Each
+	s.Snapshot().Each(func(item interface{}) bool {
+		s.Add(item.(int) * 10)
+		return true
+	})
+	if !s.IsEqual(New(1, 2, 3, 10, 20, 30)) {
+		t.Error("Each: should allow modifying the set, got", s)
+	}
+}
+
+func TestSetSnapshot_Operations(t *testing.T) {
+	s := New(1, 2, 3)
+	r := New(3, 4)
+	a, b := s.Snapshot(), r.Snapshot()
+
+	if u := Union(a, b); !u.IsEqual(New(1, 2, 3, 4)) {
+		t.Error("Union: should work on snapshots, got", u)
+	}
+	if i := Intersection(a, b); !i.IsEqual(New(3)) {
+		t.Error("Intersection: should work on snapshots, got", i)
+	}
+	if d := Difference(a, b); !d.IsEqual(New(1, 2)) {
+		t.Error("Difference: should work on snapshots, got", d)
+	}
+	if j := Jaccard(a, b); j != 0.25 {
+		t.Error("Jaccard: should work on snapshots, got", j)
+	}
+
+	c := a.Copy()
+	c.Add(5)
+	if _, ok := c.(*Set); !ok || a.Has(5) {
+		t.Error("Copy: should return a modifiable Set")
+	}
+}
+
+func TestSetSnapshot_readOnly(t *testing.T) {
+	snap := New(1).Snapshot()
+	for _, write := range []func(){
+		func() { snap.Add(2) },
+		func() { snap.Remove(1) },
+		func() { snap.Pop() },
+		func() { snap.Clear() },
+		func() { snap.Merge(New(2)) },
+		func() { snap.Separate(New(1)) },
+	} {
+		func() {
+			defer func() {
+				if err, _ := recover().(error); !errors.Is(err, ErrReadOnly) {
+					t.Error("SetSnapshot: should panic with ErrReadOnly when modified, got", err)
+				}
+			}()
+			write()
+		}()
+	}
+
+	if !snap.Has(1) || snap.Size() != 1 {
+		t.Error("SetSnapshot: should not be modified")
+	}
+}
+
+func TestSet_Snapshot_Race(t *testing.T) {
+	s := New()
+	for i := 0; i < 100; i++ {
+		s.Add(i)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				snap := s.Snapshot()
+				size := snap.Size()
+				s.Add(g*1000 + i)
+				s.Remove(g*1000 + i - 1)
+
+				n := 0
+				snap.Each(func(item interface{}) bool {
+					n++
+					return true
+				})
+				if n != size {
+					t.Error("Snapshot: should not change while it is read")
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,626 @@
+package set
+
+import (
+	"cmp"
+	"iter"
+	"math/rand/v2"
+)
+
+// CompareFunc compares two items. It returns a negative number if a sorts
+// before b, a positive number if a sorts after b and zero if both are the same
+// item.
+type CompareFunc func(a, b interface{}) int
+
+// CompareOrdered is a CompareFunc for items of an ordered type T, for example
+// NewSorted(CompareOrdered[string]). It panics if an item is not a T.
+func CompareOrdered[T cmp.Ordered](a, b interface{}) int {
+	return cmp.Compare(a.(T), b.(T))
+}
+
+// sortedNode is a node of the treap backing the sorted sets. Nodes are
+// ordered by item and heap ordered by prio, which keeps the tree balanced
+// with high probability. size is the number of items in the subtree.
+type sortedNode struct {
+	item        interface{}
+	prio        uint64
+	size        int
+	left, right *sortedNode
+}
+
+func (n *sortedNode) len() int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func (n *sortedNode) update() *sortedNode {
+	n.size = 1 + n.left.len() + n.right.len()
+	return n
+}
+
+// Provides a common sorted set baseline for both threadsafe and non-ts sorted
+// sets. Membership is decided by cmp, items do not need to be comparable.
+type sortedSet struct {
+	root *sortedNode
+	cmp  CompareFunc
+}
+
+// SortedSetNonTS defines a non-thread safe set data structure which keeps its
+// items sorted by a user supplied CompareFunc. Each, List and String visit
+// the items in ascending order. Add, Remove and Has as well as the order
+// queries run in O(log n).
+type SortedSetNonTS struct {
+	sortedSet
+}
+
+// SortedSet defines a thread safe set data structure which keeps its items
+// sorted by a user supplied CompareFunc. Each, List and String visit the
+// items in ascending order. Add, Remove and Has as well as the order queries
+// run in O(log n).
+type SortedSet struct {
+	sortedSet
+	setLock
+}
+
+// NewSortedNonTS creates and initialize a new non-threadsafe SortedSetNonTS
+// ordered by cmp. It accepts a variable number of arguments to populate the
+// initial set.
+func NewSortedNonTS(cmp CompareFunc, items ...interface{}) *SortedSetNonTS {
+	s := &SortedSetNonTS{}
+	s.cmp = cmp
+
+	// Ensure interface compliance
+	var _ Interface = s
+
+	s.Add(items...)
+	return s
+}
+
+// NewSorted creates and initialize a new SortedSet ordered by cmp. It accepts
+// a variable number of arguments to populate the initial set.
+func NewSorted(cmp CompareFunc, items ...interface{}) *SortedSet {
+	s := &SortedSet{}
+	s.cmp = cmp
+
+	// Ensure interface compliance
+	var _ Interface = s
+
+	s.Add(items...)
+	return s
+}
+
+// split splits n into the items sorting before item and the rest. If orEqual
+// is set item itself goes to the left part.
+func (s *sortedSet) split(n *sortedNode, item interface{}, orEqual bool) (l, r *sortedNode) {
+	if n == nil {
+		return nil, nil
+	}
+
+	c := s.cmp(n.item, item)
+	if c < 0 || orEqual && c == 0 {
+		n.right, r = s.split(n.right, item, orEqual)
+		return n.update(), r
+	}
+
+	l, n.left = s.split(n.left, item, orEqual)
+	return l, n.update()
+}
+
+// join joins l and r, all items of l must sort before those of r.
+func join(l, r *sortedNode) *sortedNode {
+	switch {
+	case l == nil:
+		return r
+	case r == nil:
+		return l
+	case l.prio > r.prio:
+		l.right = join(l.right, r)
+		return l.update()
+	default:
+		r.left = join(l, r.left)
+		return r.update()
+	}
+}
+
+// find returns the node holding item or nil.
+func (s *sortedSet) find(item interface{}) *sortedNode {
+	n := s.root
+	for n != nil {
+		c := s.cmp(item, n.item)
+		switch {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return n
+		}
+	}
+	return nil
+}
+
+// walk visits the items of n in ascending order and reports whether f
+// returned true for all of them.
+func walk(n *sortedNode, f func(item interface{}) bool) bool {
+	if n == nil {
+		return true
+	}
+	return walk(n.left, f) && f(n.item) && walk(n.right, f)
+}
+
+// New creates and initalizes a new SortedSetNonTS with the same order.
+func (s *sortedSet) New(items ...interface{}) Interface {
+	return NewSortedNonTS(s.cmp, items...)
+}
+
+// Add includes the specified items (one or more) to the set.
+func (s *sortedSet) Add(items ...interface{}) {
+	for _, item := range items {
+		if s.find(item) != nil {
+			continue
+		}
+
+		l, r := s.split(s.root, item, false)
+		n := &sortedNode{item: item, prio: rand.Uint64(), size: 1}
+		s.root = join(join(l, n), r)
+	}
+}
+
+// Remove deletes the specified items from the set.
+func (s *sortedSet) Remove(items ...interface{}) {
+	for _, item := range items {
+		if s.find(item) == nil {
+			continue
+		}
+
+		l, r := s.split(s.root, item, false)
+		_, r = s.split(r, item, true)
+		s.root = join(l, r)
+	}
+}
+
+// Pop deletes and returns the smallest item of the set. If set is empty, nil
+// is returned.
+func (s *sortedSet) Pop() interface{} {
+	item := s.Min()
+	if item != nil {
+		s.Remove(item)
+	}
+	return item
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (s *sortedSet) Has(items ...interface{}) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	for _, item := range items {
+		if s.find(item) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Size returns the number of items in a set.
+func (s *sortedSet) Size() int {
+	return s.root.len()
+}
+
+// Clear removes all items from the set.
+func (s *sortedSet) Clear() {
+	s.root = nil
+}
+
+// IsEmpty reports whether the set is empty.
+func (s *sortedSet) IsEmpty() bool {
+	return s.root == nil
+}
+
+// IsEqual test whether s and t are the same in size and have the same items.
+func (s *sortedSet) IsEqual(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isEqual(s, view(t))
+}
+
+// IsSubset tests whether t is a subset of s.
+func (s *sortedSet) IsSubset(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isSubset(s, view(t))
+}
+
+// IsSuperset tests whether t is a superset of s.
+func (s *sortedSet) IsSuperset(t Interface) bool {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	return isSubset(view(t), s)
+}
+
+// Each traverses the items in ascending order, calling the provided function
+// for each set member. Traversal will continue until all items in the set
+// have been visited, or if the closure returns false.
+func (s *sortedSet) Each(f func(item interface{}) bool) {
+	walk(s.root, f)
+}
+
+// All returns an iterator over the items of the set, visiting them in the
+// same order as Each.
+func (s *sortedSet) All() iter.Seq[interface{}] {
+	return func(yield func(item interface{}) bool) {
+		s.Each(yield)
+	}
+}
+
+// String returns a string representation of s in ascending order.
+func (s *sortedSet) String() string {
+	return formatItems(s.List())
+}
+
+// List returns a slice of all items in ascending order.
+func (s *sortedSet) List() []interface{} {
+	list := make([]interface{}, 0, s.Size())
+	walk(s.root, func(item interface{}) bool {
+		list = append(list, item)
+		return true
+	})
+	return list
+}
+
+// Copy returns a new SortedSetNonTS with a copy of s.
+func (s *sortedSet) Copy() Interface {
+	return NewSortedNonTS(s.cmp, s.List()...)
+}
+
+// Merge is like Union, however it modifies the current set it's applied on
+// with the given t set.
+func (s *sortedSet) Merge(t Interface) {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	s.Add(view(t).List()...)
+}
+
+// Separate removes the set items containing in t from set s. Please aware that
+// it's not the opposite of Merge.
+func (s *sortedSet) Separate(t Interface) {
+	unlock := lockSets(nil, t)
+	defer unlock()
+
+	s.Remove(view(t).List()...)
+}
+
+// Min returns the smallest item of the set, or nil if the set is empty.
+func (s *sortedSet) Min() interface{} {
+	n := s.root
+	if n == nil {
+		return nil
+	}
+	for n.left != nil {
+		n = n.left
+	}
+	return n.item
+}
+
+// Max returns the largest item of the set, or nil if the set is empty.
+func (s *sortedSet) Max() interface{} {
+	n := s.root
+	if n == nil {
+		return nil
+	}
+	for n.right != nil {
+		n = n.right
+	}
+	return n.item
+}
+
+// Floor returns the largest item less than or equal to item, or nil if there
+// is none.
+func (s *sortedSet) Floor(item interface{}) interface{} {
+	var floor interface{}
+	for n := s.root; n != nil; {
+		if s.cmp(n.item, item) <= 0 {
+			floor, n = n.item, n.right
+		} else {
+			n = n.left
+		}
+	}
+	return floor
+}
+
+// Ceiling returns the smallest item greater than or equal to item, or nil if
+// there is none.
+func (s *sortedSet) Ceiling(item interface{}) interface{} {
+	var ceiling interface{}
+	for n := s.root; n != nil; {
+		if s.cmp(n.item, item) >= 0 {
+			ceiling, n = n.item, n.left
+		} else {
+			n = n.right
+		}
+	}
+	return ceiling
+}
+
+// Range returns the items in the half-open interval [lo, hi) in ascending
+// order. It runs in O(log n + k) for k returned items.
+func (s *sortedSet) Range(lo, hi interface{}) []interface{} {
+	var items []interface{}
+	var visit func(n *sortedNode)
+	visit = func(n *sortedNode) {
+		if n == nil {
+			return
+		}
+
+		aboveLo := s.cmp(n.item, lo) >= 0
+		belowHi := s.cmp(n.item, hi) < 0
+		if aboveLo {
+			visit(n.left)
+		}
+		if aboveLo && belowHi {
+			items = append(items, n.item)
+		}
+		if belowHi {
+			visit(n.right)
+		}
+	}
+	visit(s.root)
+
+	return items
+}
+
+// Rank returns the number of items in the set which are less than item. If
+// item is in the set this is its zero based position in ascending order.
+func (s *sortedSet) Rank(item interface{}) int {
+	rank := 0
+	for n := s.root; n != nil; {
+		if s.cmp(n.item, item) < 0 {
+			rank += n.left.len() + 1
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+	return rank
+}
+
+// Select returns the item at zero based position k in ascending order, or nil
+// if k is out of range.
+func (s *sortedSet) Select(k int) interface{} {
+	if k < 0 || k >= s.Size() {
+		return nil
+	}
+
+	n := s.root
+	for {
+		switch l := n.left.len(); {
+		case k < l:
+			n = n.left
+		case k > l:
+			k -= l + 1
+			n = n.right
+		default:
+			return n.item
+		}
+	}
+}
+
+// New creates and initalizes a new SortedSet with the same order.
+func (s *SortedSet) New(items ...interface{}) Interface {
+	return NewSorted(s.cmp, items...)
+}
+
+// Add includes the specified items (one or more) to the set.
+func (s *SortedSet) Add(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.sortedSet.Add(items...)
+}
+
+// Remove deletes the specified items from the set.
+func (s *SortedSet) Remove(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.sortedSet.Remove(items...)
+}
+
+// Pop deletes and returns the smallest item of the set. If set is empty, nil
+// is returned.
+func (s *SortedSet) Pop() interface{} {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return s.sortedSet.Pop()
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (s *SortedSet) Has(items ...interface{}) bool {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.sortedSet.Has(items...)
+}
+
+// Size returns the number of items in a set.
+func (s *SortedSet) Size() int {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.root.len()
+}
+
+// Clear removes all items from the set.
+func (s *SortedSet) Clear() {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.root = nil
+}
+
+// IsEmpty reports whether the set is empty.
+func (s *SortedSet) IsEmpty() bool {
+	return s.Size() == 0
+}
+
+// IsEqual test whether s and t are the same in size and have the same items.
+func (s *SortedSet) IsEqual(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isEqual(&s.sortedSet, view(t))
+}
+
+// IsSubset tests whether t is a subset of s.
+func (s *SortedSet) IsSubset(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isSubset(&s.sortedSet, view(t))
+}
+
+// IsSuperset tests whether t is a superset of s.
+func (s *SortedSet) IsSuperset(t Interface) bool {
+	unlock := lockSets(nil, s, t)
+	defer unlock()
+
+	return isSubset(view(t), &s.sortedSet)
+}
+
+// Each traverses the items in ascending order, calling the provided function
+// for each set member. Traversal will continue until all items in the set
+// have been visited, or if the closure returns false.
+func (s *SortedSet) Each(f func(item interface{}) bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	s.sortedSet.Each(f)
+}
+
+// All returns an iterator over the items of the set, visiting them in the
+// same order as Each. As with Each, s is read locked from the start of the
+// loop until it ends, so the loop body must not modify s.
+func (s *SortedSet) All() iter.Seq[interface{}] {
+	return func(yield func(item interface{}) bool) {
+		s.Each(yield)
+	}
+}
+
+// String returns a string representation of s in ascending order.
+func (s *SortedSet) String() string {
+	return formatItems(s.List())
+}
+
+// List returns a slice of all items in ascending order.
+func (s *SortedSet) List() []interface{} {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.sortedSet.List()
+}
+
+// Copy returns a new SortedSet with a copy of s.
+func (s *SortedSet) Copy() Interface {
+	return NewSorted(s.cmp, s.List()...)
+}
+
+// Merge is like Union, however it modifies the current set it's applied on
+// with the given t set.
+func (s *SortedSet) Merge(t Interface) {
+	unlock := lockSets(s, t)
+	defer unlock()
+
+	s.sortedSet.Add(view(t).List()...)
+}
+
+// Separate removes the set items containing in t from set s. Please aware that
+// it's not the opposite of Merge.
+func (s *SortedSet) Separate(t Interface) {
+	unlock := lockSets(s, t)
+	defer unlock()
+
+	s.sortedSet.Remove(view(t).List()...)
+}
+
+// Min returns the smallest item of the set, or nil if the set is empty.
+func (s *SortedSet) Min() interface{} {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.sortedSet.Min()
+}
+
+// Max returns the largest item of the set, or nil if the set is empty.
+func (s *SortedSet) Max() interface{} {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.sortedSet.Max()
+}
+
+// Floor returns the largest item less than or equal to item, or nil if there
+// is none.
+func (s *SortedSet) Floor(item interface{}) interface{} {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.sortedSet.Floor(item)
+}
+
+// Ceiling returns the smallest item greater than or equal to item, or nil if
+// there is none.
+func (s *SortedSet) Ceiling(item interface{}) interface{} {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.sortedSet.Ceiling(item)
+}
+
+// Range returns the items in the half-open interval [lo, hi) in ascending
+// order. It runs in O(log n + k) for k returned items.
+func (s *SortedSet) Range(lo, hi interface{}) []interface{} {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.sortedSet.Range(lo, hi)
+}
+
+// Rank returns the number of items in the set which are less than item. If
+// item is in the set this is its zero based position in ascending order.
+func (s *SortedSet) Rank(item interface{}) int {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.sortedSet.Rank(item)
+}
+
+// Select returns the item at zero based position k in ascending order, or nil
+// if k is out of range.
+func (s *SortedSet) Select(k int) interface{} {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.sortedSet.Select(k)
+}
+
+// unlocked returns the underlying sorted set of s, which does not lock.
+func (s *SortedSet) unlocked() Interface {
+	return &s.sortedSet
+}
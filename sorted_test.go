@@ -0,0 +1,155 @@
+package set
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSortedSet_Order(t *testing.T) {
+	for _, s := range []Interface{NewSorted(CompareOrdered[string]), NewSortedNonTS(CompareOrdered[string])} {
+		s.Add("c", "a", "d", "b", "a")
+
+		if !reflect.DeepEqual(s.List(), []interface{}{"a", "b", "c", "d"}) {
+			t.Error("List: items should be sorted, got", s.List())
+		}
+
+		if s.String() != "[a, b, c, d]" {
+			t.Error("String: items should be sorted, got", s.String())
+		}
+
+		if item := s.Pop(); item != "a" || s.Has("a") || s.Size() != 3 {
+			t.Error("Pop: should remove and return the smallest item, got", item)
+		}
+
+		if !s.IsEqual(New("b", "c", "d")) || !s.IsSubset(NewNonTS("c")) || s.IsSuperset(New("c")) {
+			t.Error("IsEqual: wrong result against another set type")
+		}
+
+		if !reflect.DeepEqual(Union(s, New("a")).List(), []interface{}{"a", "b", "c", "d"}) {
+			t.Error("Union should derive its set type from the first passed set")
+		}
+
+		s.Clear()
+		if !s.IsEmpty() || s.Pop() != nil {
+			t.Error("Clear: set should be empty")
+		}
+	}
+}
+
+func TestSortedSet_Queries(t *testing.T) {
+	s := NewSorted(CompareOrdered[int], 10, 20, 30, 40)
+
+	if s.Min() != 10 || s.Max() != 40 {
+		t.Error("Min/Max: wrong items", s.Min(), s.Max())
+	}
+
+	if s.Floor(25) != 20 || s.Floor(20) != 20 || s.Floor(5) != nil {
+		t.Error("Floor: wrong items")
+	}
+
+	if s.Ceiling(25) != 30 || s.Ceiling(30) != 30 || s.Ceiling(45) != nil {
+		t.Error("Ceiling: wrong items")
+	}
+
+	if !reflect.DeepEqual(s.Range(15, 40), []interface{}{20, 30}) {
+		t.Error("Range: should return items in [lo, hi), got", s.Range(15, 40))
+	}
+
+	if s.Rank(10) != 0 || s.Rank(25) != 2 || s.Rank(99) != 4 {
+		t.Error("Rank: wrong ranks")
+	}
+
+	if s.Select(0) != 10 || s.Select(3) != 40 || s.Select(4) != nil || s.Select(-1) != nil {
+		t.Error("Select: wrong items")
+	}
+
+	empty := NewSortedNonTS(CompareOrdered[int])
+	if empty.Min() != nil || empty.Max() != nil || empty.Range(0, 10) != nil {
+		t.Error("Min/Max: should return nil on an empty set")
+	}
+}
+
+func TestSortedSet_CustomCompare(t *testing.T) {
+	byTime := func(a, b interface{}) int {
+		return a.(time.Time).Compare(b.(time.Time))
+	}
+	now := time.Now()
+	s := NewSorted(byTime, now.Add(time.Hour), now, now.Add(-time.Hour))
+
+	if s.Min() != now.Add(-time.Hour) || s.Rank(now) != 1 {
+		t.Error("CompareFunc: timestamps should be sorted by time")
+	}
+
+	// membership is decided by the CompareFunc, not by ==
+	folded := NewSortedNonTS(func(a, b interface{}) int {
+		return strings.Compare(strings.ToLower(a.(string)), strings.ToLower(b.(string)))
+	}, "Foo", "foo", "BAR")
+
+	if folded.Size() != 2 || !folded.Has("FOO", "bar") {
+		t.Error("CompareFunc: items comparing equal should be the same member")
+	}
+}
+
+func TestSortedSet_Random(t *testing.T) {
+	// Compare against a sorted slice after random inserts and removes.
+	r := rand.New(rand.NewSource(1))
+	s := NewSortedNonTS(CompareOrdered[int])
+	want := map[int]bool{}
+
+	for i := 0; i < 5000; i++ {
+		v := r.Intn(1000)
+		if r.Intn(3) == 0 {
+			s.Remove(v)
+			delete(want, v)
+		} else {
+			s.Add(v)
+			want[v] = true
+		}
+	}
+
+	sorted := make([]int, 0, len(want))
+	for v := range want {
+		sorted = append(sorted, v)
+	}
+	sort.Ints(sorted)
+
+	if s.Size() != len(sorted) {
+		t.Fatal("Size: got", s.Size(), "want", len(sorted))
+	}
+
+	for k, v := range sorted {
+		if s.Select(k) != v || s.Rank(v) != k {
+			t.Fatal("Select/Rank: mismatch at position", k)
+		}
+	}
+
+	if !reflect.DeepEqual(IntSlice(s), sorted) {
+		t.Error("List: items should be sorted")
+	}
+}
+
+func TestSortedSet_Race(t *testing.T) {
+	s := NewSorted(CompareOrdered[int])
+	u := NewSorted(CompareOrdered[int])
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				s.Add(g*1000 + i)
+				u.Merge(s)
+				s.Range(0, 500)
+				s.IsEqual(u)
+				s.Pop()
+			}
+		}(g)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,156 @@
+package set
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrUnhashable is matched by every *UnhashableError, use it with errors.Is.
+var ErrUnhashable = errors.New("set: unhashable item")
+
+// UnhashableError is returned for items that cannot be stored in a set
+// because they are not comparable, such as slices, maps and functions or
+// structs containing them.
+type UnhashableError struct {
+	Item interface{}
+	Type reflect.Type
+}
+
+func (e *UnhashableError) Error() string {
+	return fmt.Sprintf("set: unhashable item of type %s", e.Type)
+}
+
+// Is reports whether target is ErrUnhashable.
+func (e *UnhashableError) Is(target error) bool {
+	return target == ErrUnhashable
+}
+
+// hashable reports whether item can be used as a map key without panicking.
+func hashable(item interface{}) bool {
+	return item == nil || reflect.ValueOf(item).Comparable()
+}
+
+// checkHashable returns an *UnhashableError for the first item which cannot
+// be stored in a set.
+func checkHashable(items []interface{}) error {
+	for _, item := range items {
+		if !hashable(item) {
+			return &UnhashableError{Item: item, Type: reflect.TypeOf(item)}
+		}
+	}
+	return nil
+}
+
+// Option configures a set created by NewWith or NewNonTSWith. Options are
+// created by functions of this package such as ValidateItems, the zero Option
+// does nothing.
+type Option struct {
+	apply func(s *set)
+}
+
+// configure applies opts to s.
+func configure(s *set, opts []Option) {
+	for _, opt := range opts {
+		if opt.apply != nil {
+			opt.apply(s)
+		}
+	}
+}
+
+// ValidateItems makes Add check all passed items before adding any of them.
+// If one of them is unhashable Add panics with an *UnhashableError and the
+// set is left unchanged, instead of failing halfway through the batch with a
+// runtime error. Sets created by New and Copy of such a set validate too.
+func ValidateItems() Option {
+	return Option{apply: func(s *set) {
+		s.validate = true
+	}}
+}
+
+// NewWith creates and initialize a new empty Set configured by opts.
+func NewWith(opts ...Option) *Set {
+	s := New()
+	configure(&s.set, opts)
+	return s
+}
+
+// NewNonTSWith creates and initialize a new empty SetNonTS configured by
+// opts.
+func NewNonTSWith(opts ...Option) *SetNonTS {
+	s := NewNonTS()
+	configure(&s.set, opts)
+	return s
+}
+
+// TryAdd is like Add but returns an *UnhashableError instead of panicking if
+// one of the items cannot be stored in a set. In that case none of the items
+// are added.
+func (s *set) TryAdd(items ...interface{}) error {
+	if err := checkHashable(items); err != nil {
+		return err
+	}
+
+	s.add(items)
+	return nil
+}
+
+// TryHas is like Has but returns an *UnhashableError instead of panicking if
+// one of the items cannot be stored in a set.
+func (s *set) TryHas(items ...interface{}) (bool, error) {
+	if err := checkHashable(items); err != nil {
+		return false, err
+	}
+
+	return s.Has(items...), nil
+}
+
+// TryRemove is like Remove but returns an *UnhashableError instead of
+// panicking if one of the items cannot be stored in a set. In that case none
+// of the items are removed.
+func (s *set) TryRemove(items ...interface{}) error {
+	if err := checkHashable(items); err != nil {
+		return err
+	}
+
+	s.Remove(items...)
+	return nil
+}
+
+// TryAdd is like Add but returns an *UnhashableError instead of panicking if
+// one of the items cannot be stored in a set. In that case none of the items
+// are added.
+func (s *Set) TryAdd(items ...interface{}) error {
+	if err := checkHashable(items); err != nil {
+		return err
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.own()
+	s.add(items)
+	return nil
+}
+
+// TryHas is like Has but returns an *UnhashableError instead of panicking if
+// one of the items cannot be stored in a set.
+func (s *Set) TryHas(items ...interface{}) (bool, error) {
+	if err := checkHashable(items); err != nil {
+		return false, err
+	}
+
+	return s.Has(items...), nil
+}
+
+// TryRemove is like Remove but returns an *UnhashableError instead of
+// panicking if one of the items cannot be stored in a set. In that case none
+// of the items are removed.
+func (s *Set) TryRemove(items ...interface{}) error {
+	if err := checkHashable(items); err != nil {
+		return err
+	}
+
+	s.Remove(items...)
+	return nil
+}
@@ -0,0 +1,97 @@
+package set
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSet_TryAdd(t *testing.T) {
+	for _, s := range []interface {
+		Interface
+		TryAdd(items ...interface{}) error
+		TryHas(items ...interface{}) (bool, error)
+		TryRemove(items ...interface{}) error
+	}{New("a"), NewNonTS("a")} {
+		err := s.TryAdd("b", []int{1}, "c")
+
+		var unhashable *UnhashableError
+		if !errors.As(err, &unhashable) || !errors.Is(err, ErrUnhashable) {
+			t.Fatal("TryAdd: should return an *UnhashableError, got", err)
+		}
+
+		if unhashable.Type != reflect.TypeOf([]int{}) || !reflect.DeepEqual(unhashable.Item, []int{1}) {
+			t.Error("TryAdd: error should carry the item and its type, got", unhashable.Type)
+		}
+
+		if s.Size() != 1 {
+			t.Error("TryAdd: no item should be added if one is unhashable")
+		}
+
+		if err := s.TryAdd("b", "c"); err != nil || !s.Has("a", "b", "c") {
+			t.Error("TryAdd: hashable items should be added", err)
+		}
+
+		if has, err := s.TryHas("a", map[string]int{}); has || !errors.Is(err, ErrUnhashable) {
+			t.Error("TryHas: should return an error for a map", err)
+		}
+
+		if has, err := s.TryHas("a", "b"); !has || err != nil {
+			t.Error("TryHas: should find hashable items", err)
+		}
+
+		if err := s.TryRemove("a", func() {}); !errors.Is(err, ErrUnhashable) || !s.Has("a") {
+			t.Error("TryRemove: no item should be removed if one is unhashable", err)
+		}
+
+		if err := s.TryRemove("a"); err != nil || s.Has("a") {
+			t.Error("TryRemove: hashable items should be removed", err)
+		}
+	}
+}
+
+func TestSet_ValidateItems(t *testing.T) {
+	for _, s := range []Interface{NewWith(ValidateItems()), NewNonTSWith(ValidateItems())} {
+		s.Add("keep")
+
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, ErrUnhashable) {
+					t.Error("Add: should panic with an *UnhashableError, got", err)
+				}
+			}()
+
+			s.Add("a", struct{ M map[int]int }{}, "b")
+		}()
+
+		if !s.IsEqual(New("keep")) {
+			t.Error("Add: set should be unchanged after a rejected batch, got", s)
+		}
+
+		for _, derived := range []Interface{s.New(), s.Copy()} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("New: %T should keep validating items", derived)
+					}
+				}()
+				derived.Add([]string{"x"})
+			}()
+		}
+	}
+
+	s := NewWith(Option{})
+	s.Add("a", "b")
+	if s.validate || s.Size() != 2 {
+		t.Error("NewWith: the zero Option should do nothing")
+	}
+}
+
+func TestSet_UnhashableJSON(t *testing.T) {
+	err := json.Unmarshal([]byte(`[{"a": 1}]`), New())
+	if !errors.Is(err, ErrUnhashable) {
+		t.Error("UnmarshalJSON: error should match ErrUnhashable, got", err)
+	}
+}